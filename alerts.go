@@ -0,0 +1,240 @@
+package slackanalytics
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// AlertPeriodDay counts alert matches per day
+	AlertPeriodDay = "day"
+	// AlertPeriodMonth counts alert matches per month
+	AlertPeriodMonth = "month"
+)
+
+// AlertRule matches messages containing any of Keywords (whole words or
+// phrases, case-insensitive) or any of Regexes (over the raw text); if
+// Channels is set, only messages of those channels are checked. The rule
+// triggers for a Period (AlertPeriodDay, the default, or AlertPeriodMonth)
+// once it has matched at least Threshold messages; Name must be unique
+type AlertRule struct {
+	Name      string   `json:"name"`
+	Keywords  []string `json:"keywords"`
+	Regexes   []string `json:"regexes"`
+	Channels  []string `json:"channels"`
+	Threshold int      `json:"threshold"`
+	Period    string   `json:"period"`
+}
+
+// AlertMatch is a message matched by a rule along
+// with the keyword or regex that matched it
+type AlertMatch struct {
+	Rule      string `json:"rule"`
+	Channel   string `json:"channel"`
+	User      string `json:"user"`
+	TimeStamp string `json:"ts"`
+	Period    string `json:"period"`
+	Term      string `json:"term"`
+	Text      string `json:"text"`
+}
+
+// AlertCount is the number of messages a rule matched in a period
+type AlertCount struct {
+	Rule      string `json:"rule"`
+	Period    string `json:"period"`
+	Count     int    `json:"count"`
+	Triggered bool   `json:"triggered"`
+}
+
+// AlertReport holds the matches of all rules in message order
+// and the counts per rule and period sorted by rule and period
+type AlertReport struct {
+	Matches []AlertMatch `json:"matches"`
+	Counts  []AlertCount `json:"counts"`
+}
+
+// alertMatcher is a rule compiled for matching
+type alertMatcher struct {
+	rule     AlertRule
+	keywords [][]string
+	regexes  []*regexp.Regexp
+}
+
+// LoadAlertRules reads alert rules from a JSON file holding an array of
+// {"name": ..., "keywords": [...], "regexes": [...], "channels": [...],
+// "threshold": ..., "period": "day"|"month"} objects
+func LoadAlertRules(filePath string) (rules []AlertRule, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	rulesBytes, err := ioutil.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(rulesBytes, &rules)
+	if err != nil {
+		return
+	}
+	err = ValidateAlertRules(rules)
+	return
+}
+
+// ValidateAlertRules checks that every rule has a unique,
+// non-empty name and a known period
+func ValidateAlertRules(rules []AlertRule) error {
+	names := make(map[string]bool)
+	for _, r := range rules {
+		if r.Name == "" {
+			return errors.New("alert rule without a name")
+		}
+		if names[r.Name] {
+			return errors.New("duplicate alert rule " + r.Name)
+		}
+		names[r.Name] = true
+		if r.Period != "" && r.Period != AlertPeriodDay && r.Period != AlertPeriodMonth {
+			return errors.New("unknown period " + r.Period + " in alert rule " + r.Name)
+		}
+	}
+	return nil
+}
+
+// RunAlertRules runs rules over messages and returns every match along
+// with the match counts per period; it fails if the rules are invalid
+// (see ValidateAlertRules) or a regex does not compile
+func RunAlertRules(messages []Message, rules []AlertRule) (report AlertReport, err error) {
+	report = AlertReport{
+		Matches: []AlertMatch{},
+		Counts:  []AlertCount{},
+	}
+	if err = ValidateAlertRules(rules); err != nil {
+		return
+	}
+	matchers := make([]alertMatcher, len(rules))
+	for i, r := range rules {
+		matchers[i], err = newAlertMatcher(r)
+		if err != nil {
+			return
+		}
+	}
+	// counts holds the matches per period of each rule, by rule index
+	counts := make([]map[string]int, len(matchers))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	for _, m := range messages {
+		if m.Text == "" {
+			continue
+		}
+		words := MessageToWordsWithOptions(m, WordOptions{TrimSymbols: true, Lower: true, KeepCode: true})
+		for i, am := range matchers {
+			if len(am.rule.Channels) > 0 && !inList(m.Channel, am.rule.Channels) {
+				continue
+			}
+			term, ok := am.match(m.Text, words)
+			if !ok {
+				continue
+			}
+			period := alertPeriod(m, am.rule.Period)
+			report.Matches = append(report.Matches, AlertMatch{
+				Rule:      am.rule.Name,
+				Channel:   m.Channel,
+				User:      m.User,
+				TimeStamp: m.TimeStamp,
+				Period:    period,
+				Term:      term,
+				Text:      m.Text,
+			})
+			counts[i][period] += 1
+		}
+	}
+	for i, am := range matchers {
+		for period, c := range counts[i] {
+			report.Counts = append(report.Counts, AlertCount{
+				Rule:      am.rule.Name,
+				Period:    period,
+				Count:     c,
+				Triggered: c >= am.rule.Threshold,
+			})
+		}
+	}
+	sort.SliceStable(report.Counts, func(i, j int) bool {
+		if report.Counts[i].Rule == report.Counts[j].Rule {
+			return report.Counts[i].Period < report.Counts[j].Period
+		}
+		return report.Counts[i].Rule < report.Counts[j].Rule
+	})
+	return
+}
+
+// GetTriggeredAlerts returns the counts of an alert report
+// that reached the threshold of their rule
+func GetTriggeredAlerts(report AlertReport) (triggered []AlertCount) {
+	triggered = []AlertCount{}
+	for _, c := range report.Counts {
+		if c.Triggered {
+			triggered = append(triggered, c)
+		}
+	}
+	return
+}
+
+func newAlertMatcher(r AlertRule) (am alertMatcher, err error) {
+	am.rule = r
+	for _, k := range r.Keywords {
+		words := MessageToWords(Message{Text: k}, true, true)
+		if len(words) > 0 {
+			am.keywords = append(am.keywords, words)
+		}
+	}
+	for _, expr := range r.Regexes {
+		var re *regexp.Regexp
+		re, err = regexp.Compile(expr)
+		if err != nil {
+			return
+		}
+		am.regexes = append(am.regexes, re)
+	}
+	return
+}
+
+// match returns the first keyword or regex of the rule
+// found in a message, given its text and lowercase words
+func (am alertMatcher) match(text string, words []string) (term string, ok bool) {
+	for _, k := range am.keywords {
+		for i := 0; i+len(k) <= len(words); i++ {
+			if equalWords(words[i:i+len(k)], k) {
+				return strings.Join(k, " "), true
+			}
+		}
+	}
+	for _, re := range am.regexes {
+		if re.MatchString(text) {
+			return re.String(), true
+		}
+	}
+	return
+}
+
+// alertPeriod returns the day or month key of a message
+func alertPeriod(m Message, period string) string {
+	if period == AlertPeriodMonth {
+		return messageTime(m).Format("2006-01")
+	}
+	return messageTime(m).Format("2006-01-02")
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package slackanalytics
+
+import "testing"
+
+func TestValidateAlertRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []AlertRule
+		ok    bool
+	}{
+		{"valid", []AlertRule{{Name: "outage", Period: AlertPeriodDay}, {Name: "deploy", Period: AlertPeriodMonth}, {Name: "default"}}, true},
+		{"empty name", []AlertRule{{Keywords: []string{"down"}}}, false},
+		{"duplicate name", []AlertRule{{Name: "outage"}, {Name: "outage"}}, false},
+		{"unknown period", []AlertRule{{Name: "outage", Period: "week"}}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateAlertRules(tt.rules); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateAlertRules = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestRunAlertRulesRejectsUnknownPeriod(t *testing.T) {
+	rules := []AlertRule{{Name: "outage", Keywords: []string{"down"}, Period: "week"}}
+	if _, err := RunAlertRules([]Message{{Text: "the site is down"}}, rules); err == nil {
+		t.Error("RunAlertRules accepted an unknown period")
+	}
+}
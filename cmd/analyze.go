@@ -1,59 +1,210 @@
 package main
 
 import (
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"os"
+	"strings"
 
 	sa "github.com/korlando/slackanalytics"
 )
 
 const (
 	dataPath = "../data"
+
+	// exit codes shared by all commands
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
 )
 
+// usageError is returned by commands for invalid
+// arguments, making analyze exit with exitUsage
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// command is a subcommand of analyze
+type command struct {
+	name  string
+	short string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"stats", "Print word, score and readability stats of a data folder.", runStats},
+	{"users", "List the users of a data folder with their message counts.", runUsers},
+	{"channels", "List the channels of a data folder with their message counts.", runChannels},
+	{"export", "Analyze messages and write the stats for the dashboard.", runExport},
+	{"report", "Write an offline HTML report or a Markdown digest of the stats.", runReport},
+	{"search", "Search the messages of a data folder.", runSearch},
+	{"serve", "Serve the dashboard and a JSON API on localhost.", runServe},
+	{"compare", "Compare two snapshots, message files or data folders.", runCompare},
+}
+
+// Options holds the flags shared by the commands
+// that read and analyze a data folder
 type Options struct {
-	path    string
-	msgFile bool
+	path      string
+	msgFile   bool
+	topics    int
+	linkRules string
+	alerts    string
 }
 
-func parseFlags() (opt Options) {
-	var p, path string
-	// m is true if the path points to a JSON file containing
-	// messages alone, vs. a folder with a Slack dump
-	var m bool
-	pDefault := dataPath
-	pDesc := "Path to the data folder."
-	flag.StringVar(&p, "p", pDefault, pDesc)
-	flag.StringVar(&path, "path", pDefault, pDesc)
-	flag.BoolVar(&m, "m", false, "Path points to a JSON file containing a messages array.")
-	flag.Parse()
-	opt = Options{
-		path:    p,
-		msgFile: m,
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command named by the first argument and returns the
+// exit code; without a command (or with flags only) it keeps the old
+// behavior of printing stats, or exporting them with -m
+func run(args []string) int {
+	if len(args) > 0 && inList(args[0], []string{"help", "-h", "-help", "--help"}) {
+		// help <command> prints the flags of that command
+		if len(args) < 2 || args[0] != "help" {
+			printUsage(os.Stdout)
+			return exitOK
+		}
+		args = []string{args[1], "-h"}
 	}
-	if path != pDefault {
-		opt.path = path
+	name := "stats"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	} else if hasFlag(args, "m") {
+		name = "export"
 	}
-	return
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		err := c.run(args)
+		switch {
+		case err == nil || errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usageError{}):
+			fmt.Fprintln(os.Stderr, "analyze "+name+": "+err.Error())
+			return exitUsage
+		default:
+			fmt.Fprintln(os.Stderr, "analyze "+name+": "+err.Error())
+			return exitError
+		}
+	}
+	fmt.Fprintln(os.Stderr, "analyze: unknown command "+name)
+	printUsage(os.Stderr)
+	return exitUsage
 }
 
-func main() {
-	opt := parseFlags()
-	if opt.msgFile {
-		messages, err := sa.ReadMessagesFromFile(opt.path)
-		if err != nil {
-			log.Fatal(err)
-		}
-		sa.ExportMessageAnalysis(messages)
-		return
+func printUsage(w *os.File) {
+	fmt.Fprintln(w, "Usage: analyze <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s%s\n", c.name, c.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run analyze <command> -h for the flags of a command.")
+}
+
+// newFlagSet returns a flag set for a command that reports errors
+// instead of exiting and prints usage with the command's description
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: analyze "+name+" "+usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlagSet parses the arguments of a command, turning
+// invalid flags into usage errors
+func parseFlagSet(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError{err.Error()}
 	}
-	users, err := sa.GetUsers(opt.path)
+	return err
+}
+
+// addPathFlags adds the -p/--path flags to a flag set
+func addPathFlags(fs *flag.FlagSet, p *string) {
+	pDesc := "Path to the data folder."
+	fs.StringVar(p, "p", dataPath, pDesc)
+	fs.StringVar(p, "path", dataPath, pDesc)
+}
+
+// addAnalysisFlags adds the flags shared by stats and export
+func addAnalysisFlags(fs *flag.FlagSet, opt *Options) {
+	addPathFlags(fs, &opt.path)
+	// t is the number of topics to model, 0 to skip topic modeling
+	fs.IntVar(&opt.topics, "t", 0, "Number of topics to model (0 to skip).")
+	fs.StringVar(&opt.linkRules, "l", "", "Path to a JSON file of link classification rules.")
+	fs.StringVar(&opt.alerts, "a", "", "Path to a JSON file of keyword alert rules.")
+}
+
+// loadLinkRules replaces the link rules with
+// the ones of opt, if set
+func loadLinkRules(opt Options) error {
+	if opt.linkRules == "" {
+		return nil
+	}
+	rules, err := sa.LoadLinkRules(opt.linkRules)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	channels, err := sa.GetChannels(opt.path)
+	sa.LinkRules = rules
+	return nil
+}
+
+// loadFolder reads the users and channels of a data folder
+func loadFolder(path string) (users []*sa.User, channels []*sa.Channel, err error) {
+	users, err = sa.GetUsers(path)
 	if err != nil {
-		log.Fatal(err)
+		return
+	}
+	channels, err = sa.GetChannels(path)
+	return
+}
+
+func topicOptions(opt Options) sa.TopicOptions {
+	topicOpt := sa.DefaultTopicOptions()
+	topicOpt.NumTopics = opt.topics
+	return topicOpt
+}
+
+// inList reports whether s is one of list
+func inList(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// flagSet reports whether a flag was set on the command line
+func flagSet(fs *flag.FlagSet, name string) (set bool) {
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return
+}
+
+// hasFlag reports whether a boolean flag is set in args
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		if a == "-"+name || a == "--"+name || a == "-"+name+"=true" || a == "--"+name+"=true" {
+			return true
+		}
 	}
-	sa.GetAndPrintStats(users, channels)
+	return false
 }
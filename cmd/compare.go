@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+// runCompare compares two analysis runs; each of them is a snapshot
+// written by export, a JSON file of messages or a data folder, the
+// latter two being analyzed first
+func runCompare(args []string) error {
+	fs := newFlagSet("compare", "[flags] <old> <new>")
+	format := fs.String("format", "table", "Output format: table or json.")
+	topWords := fs.Int("n", sa.DefaultCompareOptions().TopWords, "Number of top words to compare.")
+	minTone := fs.Float64("tone", sa.DefaultCompareOptions().MinToneShift, "Smallest tone change reported as a tone shift.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError{"compare needs an old and a new snapshot"}
+	}
+	if *format != "table" && *format != "json" {
+		return usageError{"unknown format " + *format}
+	}
+	old, err := loadSnapshot(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cur, err := loadSnapshot(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	opt := sa.DefaultCompareOptions()
+	opt.TopWords = *topWords
+	opt.MinToneShift = *minTone
+	d := sa.CompareSnapshots(old, cur, opt)
+	if *format == "json" {
+		return sa.WriteSnapshotDiffJSON(os.Stdout, d)
+	}
+	return sa.WriteSnapshotDiffTable(os.Stdout, d)
+}
+
+// loadSnapshot reads the stats of a snapshot file, or analyzes
+// a JSON file of messages or a data folder
+func loadSnapshot(path string) (s sa.SlackMessageStats, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	var messages []sa.Message
+	if info.IsDir() {
+		messages, err = sa.ReadAllMessages(path)
+	} else {
+		var b []byte
+		b, err = ioutil.ReadFile(path)
+		if err != nil {
+			return
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+			return sa.LoadSlackMessageStats(path)
+		}
+		messages, err = sa.ReadMessagesFromFile(path)
+	}
+	if err != nil {
+		return
+	}
+	s = sa.AnalyzeMessages(messages)
+	return
+}
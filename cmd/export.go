@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+// runExport analyzes the messages of a data folder, or of a JSON file
+// of messages with -m, and writes the stats to the dashboard folder
+// or wherever --out points to
+func runExport(args []string) error {
+	fs := newFlagSet("export", "[flags]")
+	var opt Options
+	addAnalysisFlags(fs, &opt)
+	exportOpt := sa.DefaultExportOptions()
+	fs.StringVar(&exportOpt.Path, "out", exportOpt.Path, "File or folder to write to, - for stdout.")
+	fs.StringVar(&exportOpt.Format, "format", exportOpt.Format, "Output format: "+strings.Join(sa.ExportFormats, ", ")+", or with --tables "+strings.Join(sa.TableFormats, ", ")+".")
+	sqlite := fs.String("sqlite", "", "Write users, channels, messages, reactions and stats of a data folder to this SQLite file instead.")
+	tables := fs.Bool("tables", false, "Write spreadsheet tables (users, channels, days, months, words, emojis, categories) to the --out folder, ./tables by default.")
+	// m is true if the path points to a JSON file containing
+	// messages alone, vs. a folder with a Slack dump
+	fs.BoolVar(&opt.msgFile, "m", false, "Path points to a JSON file containing a messages array.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"unexpected argument " + fs.Arg(0)}
+	}
+	if *tables {
+		if !flagSet(fs, "out") {
+			exportOpt.Path = "./tables"
+		}
+		if !flagSet(fs, "format") {
+			exportOpt.Format = sa.TableCSV
+		}
+		if !inList(exportOpt.Format, sa.TableFormats) {
+			return usageError{"unknown table format " + exportOpt.Format}
+		}
+	} else if !inList(exportOpt.Format, sa.ExportFormats) {
+		return usageError{"unknown format " + exportOpt.Format}
+	}
+	if *sqlite != "" && opt.msgFile {
+		return usageError{"--sqlite needs a data folder, not -m"}
+	}
+	if err := loadLinkRules(opt); err != nil {
+		return err
+	}
+	if *sqlite != "" {
+		users, channels, err := loadFolder(opt.path)
+		if err != nil {
+			return err
+		}
+		var messages []sa.Message
+		for _, c := range channels {
+			messages = append(messages, c.Messages...)
+		}
+		return sa.ExportSQLite(*sqlite, users, channels, sa.AnalyzeMessages(messages))
+	}
+	var messages []sa.Message
+	var err error
+	if opt.msgFile {
+		messages, err = sa.ReadMessagesFromFile(opt.path)
+	} else {
+		messages, err = sa.ReadAllMessages(opt.path)
+	}
+	if err != nil {
+		return err
+	}
+	s := sa.AnalyzeMessages(messages)
+	if opt.topics > 0 {
+		s.Topics = sa.BuildTopicModel(messages, topicOptions(opt))
+	}
+	if opt.alerts != "" {
+		// keep stdout clean when the stats are written to it
+		var w io.Writer = os.Stdout
+		if exportOpt.Path == sa.ExportStdout {
+			w = os.Stderr
+		}
+		report, err := runAlerts(opt, messages, w)
+		if err != nil {
+			return err
+		}
+		s.Alerts = &report
+	}
+	if *tables {
+		return sa.ExportTables(sa.GetMessageStatsTables(s), exportOpt.Path, exportOpt.Format)
+	}
+	return sa.ExportSlackMessageStatsWithOptions(s, exportOpt)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+// runReport analyzes the messages of a data folder, or of a JSON file
+// of messages with -m, and writes an offline HTML report or a Markdown digest
+func runReport(args []string) error {
+	fs := newFlagSet("report", "[flags]")
+	var opt Options
+	addPathFlags(fs, &opt.path)
+	fs.BoolVar(&opt.msgFile, "m", false, "Path points to a JSON file containing a messages array.")
+	fs.StringVar(&opt.linkRules, "l", "", "Path to a JSON file of link classification rules.")
+	out := fs.String("out", "", "File to write the report to, - for stdout (report.html or digest.md by default).")
+	format := fs.String("format", "html", "Report format: html, or markdown for a short digest.")
+	tmplPath := fs.String("template", "", "Path to a text/template for the Markdown digest.")
+	amount := fs.Int("n", 20, "Number of top words and emojis to show.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"unexpected argument " + fs.Arg(0)}
+	}
+	var render func(w io.Writer, r sa.Report) error
+	switch *format {
+	case "html":
+		render = sa.RenderHTMLReport
+		if *out == "" {
+			*out = "report.html"
+		}
+	case "markdown", "md":
+		tmpl, err := loadDigestTemplate(*tmplPath)
+		if err != nil {
+			return err
+		}
+		render = func(w io.Writer, r sa.Report) error {
+			return sa.RenderMarkdownDigest(w, r, tmpl)
+		}
+		if *out == "" {
+			*out = "digest.md"
+		}
+	default:
+		return usageError{"unknown format " + *format}
+	}
+	r, err := buildReport(opt, *amount)
+	if err != nil {
+		return err
+	}
+	return writeReport(*out, r, render)
+}
+
+// loadDigestTemplate parses the digest template at
+// path, or returns the default one if path is empty
+func loadDigestTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return sa.DefaultDigestTemplate, nil
+	}
+	return sa.ParseDigestTemplate(path)
+}
+
+// writeReport renders a report to the file out,
+// creating its folder, or to stdout if out is -
+func writeReport(out string, r sa.Report, render func(w io.Writer, r sa.Report) error) error {
+	if out == sa.ExportStdout {
+		return render(os.Stdout, r)
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = render(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// buildReport reads the messages (and users, for a data
+// folder) of opt and builds a report of their stats
+func buildReport(opt Options, amount int) (r sa.Report, err error) {
+	if err = loadLinkRules(opt); err != nil {
+		return
+	}
+	var users []*sa.User
+	var messages []sa.Message
+	if opt.msgFile {
+		messages, err = sa.ReadMessagesFromFile(opt.path)
+	} else {
+		users, err = sa.GetUsers(opt.path)
+		if err != nil {
+			return
+		}
+		messages, err = sa.ReadAllMessages(opt.path)
+	}
+	if err != nil {
+		return
+	}
+	r = sa.BuildReport(sa.AnalyzeMessages(messages), users, amount)
+	return
+}
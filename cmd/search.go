@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+const (
+	searchIndexFile = "search-index.json"
+)
+
+// runSearch runs the search subcommand: it builds the index of the
+// data folder if there is none yet, the index is of another folder or
+// older than the data (or -rebuild is set) and prints the messages
+// matching the query
+func runSearch(args []string) error {
+	fs := newFlagSet("search", "[flags] query")
+	var p, index string
+	addPathFlags(fs, &p)
+	fs.StringVar(&index, "i", searchIndexFile, "Path to the search index file.")
+	rebuild := fs.Bool("rebuild", false, "Rebuild the search index.")
+	limit := fs.Int("n", 20, "Maximum number of results to print (0 for all).")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: analyze search [flags] query")
+		fmt.Fprintln(fs.Output(), "Query: words, \"phrases\", AND, OR, NOT/-, ( ), from:user in:channel before:YYYY-MM-DD after:YYYY-MM-DD")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError{"missing query"}
+	}
+	q, err := sa.ParseSearchQuery(strings.Join(fs.Args(), " "))
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	source, err := filepath.Abs(p)
+	if err != nil {
+		return err
+	}
+	modTime, err := sa.DataModTime(p)
+	if err != nil {
+		return err
+	}
+	idx, err := sa.LoadSearchIndex(index)
+	if err != nil || *rebuild || sa.IsSearchIndexStale(idx, source, modTime) {
+		idx, err = buildSearchIndex(p)
+		if err != nil {
+			return err
+		}
+		idx.Source = source
+		idx.SourceModTime = modTime
+		if err = sa.SaveSearchIndex(idx, index); err != nil {
+			return err
+		}
+	}
+	results := sa.Search(idx, q)
+	fmt.Println(strconv.Itoa(len(results)) + " results")
+	for i, d := range results {
+		if *limit > 0 && i >= *limit {
+			break
+		}
+		name := d.UserName
+		if name == "" {
+			name = d.User
+		}
+		ts, _ := strconv.ParseFloat(d.TimeStamp, 64)
+		t := time.Unix(int64(ts), 0).Format("2006-01-02 15:04")
+		fmt.Println("#" + d.Channel + " " + name + " " + t)
+		fmt.Println("  " + strings.ReplaceAll(d.Text, "\n", "\n  "))
+	}
+	return nil
+}
+
+// buildSearchIndex indexes the messages of a data folder
+func buildSearchIndex(path string) (idx *sa.SearchIndex, err error) {
+	users, err := sa.GetUsers(path)
+	if err != nil {
+		return
+	}
+	messages, err := sa.ReadAllMessages(path)
+	if err != nil {
+		return
+	}
+	idx = sa.BuildSearchIndex(messages, users)
+	return
+}
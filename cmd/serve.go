@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+// runServe analyzes the messages of a data folder, or of a JSON file
+// of messages with -m, once and serves the JSON API and the dashboard
+func runServe(args []string) error {
+	fs := newFlagSet("serve", "[flags]")
+	var opt Options
+	addPathFlags(fs, &opt.path)
+	fs.BoolVar(&opt.msgFile, "m", false, "Path points to a JSON file containing a messages array.")
+	fs.StringVar(&opt.linkRules, "l", "", "Path to a JSON file of link classification rules.")
+	addr := fs.String("addr", "localhost:8080", "Address to listen on.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"unexpected argument " + fs.Arg(0)}
+	}
+	if err := loadLinkRules(opt); err != nil {
+		return err
+	}
+	var users []*sa.User
+	var messages []sa.Message
+	var err error
+	if opt.msgFile {
+		messages, err = sa.ReadMessagesFromFile(opt.path)
+	} else {
+		users, err = sa.GetUsers(opt.path)
+		if err != nil {
+			return err
+		}
+		messages, err = sa.ReadAllMessages(opt.path)
+	}
+	if err != nil {
+		return err
+	}
+	srv := sa.NewServer(sa.AnalyzeMessages(messages), users, sa.BuildSearchIndex(messages, users))
+	fmt.Println("Serving the dashboard on http://" + *addr)
+	return http.ListenAndServe(*addr, srv)
+}
@@ -0,0 +1,4 @@
+package main
+
+// register the pure-Go SQLite driver used by export --sqlite
+import _ "modernc.org/sqlite"
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+// runStats prints the stats of a data folder along with its topics,
+// trending words, triggered alerts and unanswered questions if asked
+// to, and writes a Markdown digest of them with --digest
+func runStats(args []string) error {
+	fs := newFlagSet("stats", "[flags]")
+	var opt Options
+	addAnalysisFlags(fs, &opt)
+	questions := fs.Bool("q", false, "Print the unanswered questions of each channel.")
+	trends := fs.Bool("trends", false, "Print the words trending in the latest month, overall and per channel.")
+	trendMonth := fs.String("trend-month", "", "Month (YYYY-MM) to print trends of instead of the latest one.")
+	workspace := fs.String("w", "", "Workspace URL used for permalinks, e.g. https://acme.slack.com.")
+	tables := fs.String("tables", "", "Folder to also write spreadsheet tables (users, channels, words, categories) to.")
+	format := fs.String("format", sa.TableCSV, "Table format: "+strings.Join(sa.TableFormats, ", ")+".")
+	digest := fs.String("digest", "", "File to also write a Markdown digest of the stats to, - for stdout.")
+	digestTmpl := fs.String("template", "", "Path to a text/template for the --digest digest.")
+	plain := fs.Bool("plain", false, "Draw charts with ASCII even on a terminal.")
+	width := fs.Int("width", sa.DefaultTerminalOptions().Width, "Widest a chart bar may be.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"unexpected argument " + fs.Arg(0)}
+	}
+	if !inList(*format, sa.TableFormats) {
+		return usageError{"unknown table format " + *format}
+	}
+	if err := loadLinkRules(opt); err != nil {
+		return err
+	}
+	tmpl, err := loadDigestTemplate(*digestTmpl)
+	if err != nil {
+		return err
+	}
+	users, channels, err := loadFolder(opt.path)
+	if err != nil {
+		return err
+	}
+	printOpt := sa.DefaultTerminalOptions()
+	printOpt.Fancy = printOpt.Fancy && !*plain
+	if *width > 0 {
+		printOpt.Width = *width
+	}
+	ss := sa.GetAndPrintStatsWithOptions(users, channels, printOpt)
+	if *tables != "" {
+		if err := sa.ExportTables(sa.GetSlackStatsTables(ss, users, channels), *tables, *format); err != nil {
+			return err
+		}
+	}
+	if *digest != "" {
+		r := sa.BuildSlackStatsReport(ss, users, channels, printOpt.TopWords)
+		err := writeReport(*digest, r, func(w io.Writer, r sa.Report) error {
+			return sa.RenderMarkdownDigest(w, r, tmpl)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	var messages []sa.Message
+	for _, c := range channels {
+		messages = append(messages, c.Messages...)
+	}
+	if opt.topics > 0 {
+		tm := sa.BuildTopicModel(messages, topicOptions(opt))
+		fmt.Println("Topics:")
+		for _, t := range tm.Topics {
+			words := []string{}
+			for _, w := range t.TopWords {
+				words = append(words, w.Word)
+			}
+			fmt.Println(strconv.Itoa(t.Id) + ": " + strings.Join(words, " "))
+		}
+	}
+	if *trends || *trendMonth != "" {
+		trendOpt := sa.DefaultTrendOptions()
+		trendOpt.Period = *trendMonth
+		sa.WriteTrendReport(os.Stdout, sa.GetTrendReport(messages, trendOpt), 5)
+		fmt.Println()
+	}
+	if opt.alerts != "" {
+		if _, err := runAlerts(opt, messages, os.Stdout); err != nil {
+			return err
+		}
+	}
+	if *questions {
+		questionOpt := sa.DefaultQuestionOptions()
+		questionOpt.WorkspaceURL = *workspace
+		report := sa.FindQuestions(channels, questionOpt)
+		fmt.Println("Questions: " + strconv.Itoa(report.NumQuestions) + ", answered: " + strconv.Itoa(report.NumAnswered))
+		for _, c := range channels {
+			questions := report.Unanswered[c.Name]
+			if len(questions) == 0 {
+				continue
+			}
+			fmt.Println("Unanswered in #" + c.Name + ":")
+			for _, q := range questions {
+				fmt.Println("  " + q.Permalink + " " + q.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// runAlerts runs the alert rules of opt over messages
+// and prints the periods in which a rule triggered to w
+func runAlerts(opt Options, messages []sa.Message, w io.Writer) (report sa.AlertReport, err error) {
+	rules, err := sa.LoadAlertRules(opt.alerts)
+	if err != nil {
+		return
+	}
+	report, err = sa.RunAlertRules(messages, rules)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(w, "Alerts:")
+	for _, c := range sa.GetTriggeredAlerts(report) {
+		fmt.Fprintln(w, c.Rule+" "+c.Period+": "+strconv.Itoa(c.Count)+" messages")
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	sa "github.com/korlando/slackanalytics"
+)
+
+// runUsers lists the users of a data folder, most active first
+func runUsers(args []string) error {
+	fs := newFlagSet("users", "[flags]")
+	var p string
+	addPathFlags(fs, &p)
+	deleted := fs.Bool("deleted", false, "Include deleted users.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"unexpected argument " + fs.Arg(0)}
+	}
+	users, err := sa.GetUsers(p)
+	if err != nil {
+		return err
+	}
+	messages, err := sa.ReadAllMessages(p)
+	if err != nil {
+		return err
+	}
+	counts := make(map[string]int)
+	for _, m := range messages {
+		counts[m.User] += 1
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return counts[users[i].Id] > counts[users[j].Id]
+	})
+	fmt.Printf("%-12s %-24s %8s\n", "ID", "NAME", "MESSAGES")
+	for _, u := range users {
+		if u.Deleted && !*deleted {
+			continue
+		}
+		fmt.Printf("%-12s %-24s %8d\n", u.Id, sa.GetUserName(u), counts[u.Id])
+	}
+	return nil
+}
+
+// runChannels lists the channels of a data folder, most active first
+func runChannels(args []string) error {
+	fs := newFlagSet("channels", "[flags]")
+	var p string
+	addPathFlags(fs, &p)
+	archived := fs.Bool("archived", false, "Include archived channels.")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"unexpected argument " + fs.Arg(0)}
+	}
+	channels, err := sa.GetChannels(p)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(channels, func(i, j int) bool {
+		return len(channels[i].Messages) > len(channels[j].Messages)
+	})
+	fmt.Printf("%-12s %-24s %8s %8s\n", "ID", "NAME", "MEMBERS", "MESSAGES")
+	for _, c := range channels {
+		if c.IsArchived && !*archived {
+			continue
+		}
+		fmt.Printf("%-12s %-24s %8d %8d\n", c.Id, "#"+c.Name, len(c.Members), len(c.Messages))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package slackanalytics
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxErrorSignatureLength caps the length (in runes) of an error signature
+	maxErrorSignatureLength = 120
+)
+
+var (
+	codeFenceRegexp    = regexp.MustCompile("(?s)```(.*?)```")
+	inlineCodeRegexp   = regexp.MustCompile("`([^`\n]+)`")
+	fenceHintRegexp    = regexp.MustCompile(`^([a-zA-Z0-9+#-]{1,12})\s*\n`)
+	errorLineRegexp    = regexp.MustCompile(`(?i)\b(\w*(error|exception)|panic|fatal)\b.*`)
+	stackFrameRegexp   = regexp.MustCompile(`(?m)^(\s+at [\w$.<>]+\(|\s*File ".+", line \d+|goroutine \d+ \[|\s+\S+\.go:\d+)`)
+	sigPathRegexp      = regexp.MustCompile(`(?:[A-Za-z]:)?(?:[\w.-]*[/\\])+[\w.-]+(?::\d+)*`)
+	sigQuotedRegexp    = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	sigNumberRegexp    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b\d+(\.\d+)*\b`)
+	sigSpacesRegexp    = regexp.MustCompile(`\s+`)
+	shellCommandRegexp = regexp.MustCompile(`(?m)^\s*(\$ |sudo |git |npm |yarn |docker |kubectl |curl |cd |ls |go (run|build|test) |pip |make )`)
+
+	// codeFenceLanguages lists the language names accepted as a
+	// hint on the first line of a fenced block (```go)
+	codeFenceLanguages = []string{"bash", "c", "c#", "c++", "cpp", "csharp", "css", "diff", "go", "golang", "html", "java", "javascript", "js", "json", "kotlin", "php", "py", "python", "rb", "ruby", "rust", "scala", "sh", "shell", "sql", "swift", "ts", "typescript", "xml", "yaml", "yml"}
+
+	// codeLanguageHints holds, in order of precedence, the
+	// patterns used to guess the language of a snippet
+	codeLanguageHints = []struct {
+		language string
+		pattern  *regexp.Regexp
+	}{
+		{"python", regexp.MustCompile(`Traceback \(most recent call last\)|(?m)^\s*def \w+\(.*\):|(?m)^\s*from [\w.]+ import |self\.\w+`)},
+		{"go", regexp.MustCompile(`(?m)^package \w+|(?m)^\s*func [\w(]|:= |goroutine \d+ \[|\.go:\d+|^panic: `)},
+		{"java", regexp.MustCompile(`Exception in thread|(?m)^\s+at [\w$.]+\(|public (static )?(class|void)|System\.out\.`)},
+		{"sql", regexp.MustCompile(`(?i)\b(select .+ from|insert into|create table|update \w+ set|delete from)\b`)},
+		{"javascript", regexp.MustCompile(`console\.log|=>|(?m)^\s*(const|let|var) \w+ =|function\s*\w*\(|require\(`)},
+		{"cpp", regexp.MustCompile(`#include\s*<|std::`)},
+		{"rust", regexp.MustCompile(`\bfn \w+\(|let mut |println!`)},
+		{"php", regexp.MustCompile(`<\?php`)},
+		{"html", regexp.MustCompile(`(?i)<(html|div|span|body|head|script)\b`)},
+		{"shell", shellCommandRegexp},
+		{"yaml", regexp.MustCompile(`(?m)^(apiVersion|kind|metadata|services|version):`)},
+	}
+)
+
+// CodeSnippet is a piece of code found in a message, either a fenced
+// block (```), inline code (`) or an unfenced stack trace
+type CodeSnippet struct {
+	Text     string
+	Language string
+	Lines    int
+	Inline   bool
+}
+
+// CodeStats holds statistics about the code shared in a set of messages;
+// CodeShare is the share of messages that contain code
+type CodeStats struct {
+	NumCodeMessages int            `json:"num_code_messages"`
+	NumSnippets     int            `json:"num_snippets"`
+	NumInline       int            `json:"num_inline"`
+	NumLines        int            `json:"num_lines"`
+	CodeShare       float64        `json:"code_share"`
+	LanguageCounts  map[string]int `json:"language_counts"`
+	ErrorCounts     map[string]int `json:"error_counts"`
+}
+
+// ExtractCode takes in message text and returns the text with all code
+// replaced by spaces along with the code snippets found; a message without
+// code fences that looks like a stack trace is returned as a single snippet
+func ExtractCode(text string) (prose string, snippets []CodeSnippet) {
+	prose = codeFenceRegexp.ReplaceAllStringFunc(text, func(s string) string {
+		code := codeFenceRegexp.FindStringSubmatch(s)[1]
+		snippets = append(snippets, newCodeSnippet(code, false))
+		return " "
+	})
+	prose = inlineCodeRegexp.ReplaceAllStringFunc(prose, func(s string) string {
+		snippets = append(snippets, newCodeSnippet(strings.Trim(s, "`"), true))
+		return " "
+	})
+	if len(snippets) == 0 && len(stackFrameRegexp.FindAllString(text, 2)) >= 2 {
+		snippets = append(snippets, newCodeSnippet(text, false))
+		prose = ""
+	}
+	return
+}
+
+// StripCode takes in message text and returns it without any code
+func StripCode(text string) string {
+	prose, _ := ExtractCode(text)
+	return prose
+}
+
+// GuessCodeLanguage guesses the programming language of a code snippet,
+// returning "json" for valid JSON and "text" if nothing matches
+func GuessCodeLanguage(code string) string {
+	trimmed := strings.TrimSpace(code)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+	for _, hint := range codeLanguageHints {
+		if hint.pattern.MatchString(code) {
+			return hint.language
+		}
+	}
+	return "text"
+}
+
+// GetErrorSignatures takes in code and returns the signature of every
+// error line in it: the line from the error keyword on, with paths, quoted
+// values and numbers replaced so that the same error pasted twice matches
+func GetErrorSignatures(code string) (signatures []string) {
+	for _, line := range strings.Split(code, "\n") {
+		match := errorLineRegexp.FindString(line)
+		if match == "" {
+			continue
+		}
+		sig := sigPathRegexp.ReplaceAllString(match, "<path>")
+		sig = sigQuotedRegexp.ReplaceAllString(sig, "<value>")
+		sig = sigNumberRegexp.ReplaceAllString(sig, "<n>")
+		sig = strings.TrimSpace(sigSpacesRegexp.ReplaceAllString(sig, " "))
+		if r := []rune(sig); len(r) > maxErrorSignatureLength {
+			sig = string(r[:maxErrorSignatureLength])
+		}
+		signatures = append(signatures, sig)
+	}
+	return
+}
+
+// GetTopErrorSignatures takes in code stats and returns the
+// amount most pasted error signatures by frequency descending
+func GetTopErrorSignatures(cs CodeStats, amount int) []WordCount {
+	return topCounts(cs.ErrorCounts, amount)
+}
+
+// newCodeSnippet builds a snippet from code, using a language
+// hint on the first line of a fenced block if there is one
+func newCodeSnippet(code string, inline bool) CodeSnippet {
+	snippet := CodeSnippet{Inline: inline}
+	if !inline {
+		if hint := fenceHintRegexp.FindStringSubmatch(code); hint != nil && inList(strings.ToLower(hint[1]), codeFenceLanguages) {
+			snippet.Language = strings.ToLower(hint[1])
+			code = code[len(hint[0]):]
+		}
+	}
+	snippet.Text = strings.Trim(code, "\n")
+	snippet.Lines = strings.Count(snippet.Text, "\n") + 1
+	if snippet.Language == "" {
+		snippet.Language = GuessCodeLanguage(snippet.Text)
+	}
+	return snippet
+}
+
+func newCodeStats() CodeStats {
+	return CodeStats{
+		LanguageCounts: make(map[string]int),
+		ErrorCounts:    make(map[string]int),
+	}
+}
+
+// addSnippets adds the code snippets of a single message
+func (cs *CodeStats) addSnippets(snippets []CodeSnippet) {
+	if len(snippets) == 0 {
+		return
+	}
+	cs.NumCodeMessages += 1
+	for _, s := range snippets {
+		if s.Inline {
+			cs.NumInline += 1
+			continue
+		}
+		cs.NumSnippets += 1
+		cs.NumLines += s.Lines
+		cs.LanguageCounts[s.Language] += 1
+		for _, sig := range GetErrorSignatures(s.Text) {
+			cs.ErrorCounts[sig] += 1
+		}
+	}
+}
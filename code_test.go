@@ -0,0 +1,27 @@
+package slackanalytics
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGetErrorSignaturesTruncatesRunes(t *testing.T) {
+	sigs := GetErrorSignatures("error: " + strings.Repeat("é", 200))
+	if len(sigs) != 1 {
+		t.Fatalf("got %d signatures, want 1", len(sigs))
+	}
+	if !utf8.ValidString(sigs[0]) || utf8.RuneCountInString(sigs[0]) != maxErrorSignatureLength {
+		t.Errorf("signature %q is not %d valid runes", sigs[0], maxErrorSignatureLength)
+	}
+}
+
+func TestCodeOnlyUserCodeShare(t *testing.T) {
+	s := AnalyzeMessages([]Message{
+		{User: "U1", Text: "```\npanic: runtime error\n```", TimeStamp: "1600000000.000100"},
+		{User: "U2", Text: "looks like a nil map", TimeStamp: "1600000100.000100"},
+	})
+	if got := s.UserStats["U1"].Code.CodeShare; got != 1 {
+		t.Errorf("CodeShare of a user posting only code = %v, want 1", got)
+	}
+}
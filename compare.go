@@ -0,0 +1,259 @@
+package slackanalytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+const (
+	// GroupChanged marks a user or channel found in both snapshots
+	GroupChanged = "changed"
+	// GroupNew marks a user or channel only found in the new snapshot
+	GroupNew = "new"
+	// GroupVanished marks a user or channel only found in the old snapshot
+	GroupVanished = "vanished"
+)
+
+// CompareOptions configures CompareSnapshots: Metrics are names of
+// StatsColumns, TopWords is the size of the top word lists compared
+// and MinToneShift the tone change (on the 0-100 scale) worth reporting
+type CompareOptions struct {
+	Metrics      []string
+	TopWords     int
+	MinToneShift float64
+}
+
+// MetricChange is a metric in the old and new snapshot
+type MetricChange struct {
+	Metric string  `json:"metric"`
+	Old    float64 `json:"old"`
+	New    float64 `json:"new"`
+	Change float64 `json:"change"`
+}
+
+// GroupDiff is how the metrics of a user or channel changed
+type GroupDiff struct {
+	Kind    string         `json:"kind"`
+	Key     string         `json:"key"`
+	Status  string         `json:"status"`
+	Changes []MetricChange `json:"changes"`
+}
+
+// ToneShift is a user or channel whose tone changed
+// by at least CompareOptions.MinToneShift
+type ToneShift struct {
+	Kind    string  `json:"kind"`
+	Key     string  `json:"key"`
+	OldTone float64 `json:"old_tone"`
+	NewTone float64 `json:"new_tone"`
+	Change  float64 `json:"change"`
+}
+
+// SnapshotDiff is the difference between two analysis runs
+type SnapshotDiff struct {
+	OldTime          int            `json:"old_time"`
+	NewTime          int            `json:"new_time"`
+	Overall          []MetricChange `json:"overall"`
+	Users            []GroupDiff    `json:"users"`
+	Channels         []GroupDiff    `json:"channels"`
+	NewTopWords      []string       `json:"new_top_words"`
+	VanishedTopWords []string       `json:"vanished_top_words"`
+	ToneShifts       []ToneShift    `json:"tone_shifts"`
+}
+
+// DefaultCompareOptions compares activity, length and summary
+// scores, the top 20 words and tone shifts of 5 points or more
+func DefaultCompareOptions() CompareOptions {
+	return CompareOptions{
+		Metrics:      []string{"num_messages", "num_words", "num_emojis", "avg_words_per_msg", "clout", "tone", "analytic", "flesch_reading_ease"},
+		TopWords:     20,
+		MinToneShift: 5,
+	}
+}
+
+// LoadSlackMessageStats reads a snapshot written by ExportSlackMessageStats;
+// snapshots of another schema version are rejected (see StatsSchemaVersion)
+func LoadSlackMessageStats(filePath string) (s SlackMessageStats, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	statsBytes, err := ioutil.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(statsBytes, &s); err != nil {
+		return
+	}
+	if err = CheckSchemaVersion(s); err != nil {
+		err = fmt.Errorf("%s: %v", filePath, err)
+	}
+	return
+}
+
+// CompareSnapshots compares two analysis runs: the overall metrics, the
+// metrics of every user and channel, the top words and the tone shifts
+func CompareSnapshots(old, cur SlackMessageStats, opt CompareOptions) (d SnapshotDiff) {
+	d = SnapshotDiff{
+		OldTime:          old.Time,
+		NewTime:          cur.Time,
+		Users:            compareGroups("user", old.UserStats, cur.UserStats, opt.Metrics),
+		Channels:         compareGroups("channel", old.ChannelStats, cur.ChannelStats, opt.Metrics),
+		NewTopWords:      []string{},
+		VanishedTopWords: []string{},
+		ToneShifts:       []ToneShift{},
+	}
+	oldAll, newAll := old.AllStats, cur.AllStats
+	if oldAll == nil {
+		oldAll = newMessageStats()
+	}
+	if newAll == nil {
+		newAll = newMessageStats()
+	}
+	d.Overall = compareMetrics(oldAll, newAll, opt.Metrics)
+
+	oldTop := make(map[string]bool)
+	for _, wc := range GetTopReportWords(oldAll, opt.TopWords) {
+		oldTop[wc.Word] = true
+	}
+	newTop := make(map[string]bool)
+	for _, wc := range GetTopReportWords(newAll, opt.TopWords) {
+		newTop[wc.Word] = true
+		if !oldTop[wc.Word] {
+			d.NewTopWords = append(d.NewTopWords, wc.Word)
+		}
+	}
+	for _, wc := range GetTopReportWords(oldAll, opt.TopWords) {
+		if !newTop[wc.Word] {
+			d.VanishedTopWords = append(d.VanishedTopWords, wc.Word)
+		}
+	}
+
+	for _, g := range append(append([]GroupDiff{}, d.Users...), d.Channels...) {
+		if g.Status != GroupChanged {
+			continue
+		}
+		for _, c := range g.Changes {
+			if c.Metric == "tone" && math.Abs(c.Change) >= opt.MinToneShift {
+				d.ToneShifts = append(d.ToneShifts, ToneShift{Kind: g.Kind, Key: g.Key, OldTone: c.Old, NewTone: c.New, Change: c.Change})
+			}
+		}
+	}
+	if len(d.Overall) > 0 {
+		oldTone, newTone := oldAll.Scores.Tone, newAll.Scores.Tone
+		if math.Abs(newTone-oldTone) >= opt.MinToneShift {
+			d.ToneShifts = append(d.ToneShifts, ToneShift{Kind: "all", OldTone: oldTone, NewTone: newTone, Change: newTone - oldTone})
+		}
+	}
+	sort.SliceStable(d.ToneShifts, func(i, j int) bool {
+		return math.Abs(d.ToneShifts[i].Change) > math.Abs(d.ToneShifts[j].Change)
+	})
+	return
+}
+
+// WriteSnapshotDiffJSON writes a snapshot diff as indented JSON
+func WriteSnapshotDiffJSON(w io.Writer, d SnapshotDiff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "	")
+	return enc.Encode(d)
+}
+
+// WriteSnapshotDiffTable writes a snapshot diff as aligned tables
+// of metric changes followed by top word and tone changes
+func WriteSnapshotDiffTable(w io.Writer, d SnapshotDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "KIND\tKEY\tSTATUS\tMETRIC\tOLD\tNEW\tCHANGE\t")
+	writeChanges := func(kind, key, status string, changes []MetricChange) {
+		for _, c := range changes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", kind, key, status, c.Metric, floatStr(c.Old, 2), floatStr(c.New, 2), signedFloatStr(c.Change, 2))
+		}
+	}
+	writeChanges("all", "", GroupChanged, d.Overall)
+	for _, g := range append(append([]GroupDiff{}, d.Users...), d.Channels...) {
+		writeChanges(g.Kind, g.Key, g.Status, g.Changes)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "New top words:", joinOrNone(d.NewTopWords))
+	fmt.Fprintln(w, "Vanished top words:", joinOrNone(d.VanishedTopWords))
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "TONE SHIFT\tKEY\tOLD\tNEW\tCHANGE\t")
+	for _, s := range d.ToneShifts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", s.Kind, s.Key, floatStr(s.OldTone, 2), floatStr(s.NewTone, 2), signedFloatStr(s.Change, 2))
+	}
+	return tw.Flush()
+}
+
+// compareGroups compares the users or channels of two snapshots;
+// groups only found in one of them are marked new or vanished
+func compareGroups(kind string, old, cur map[string]*MessageStats, metrics []string) (diffs []GroupDiff) {
+	diffs = []GroupDiff{}
+	keys := sortedStatsKeys(old)
+	for _, k := range sortedStatsKeys(cur) {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		g := GroupDiff{Kind: kind, Key: k, Status: GroupChanged}
+		oldStats, inOld := old[k]
+		newStats, inNew := cur[k]
+		if !inOld {
+			g.Status = GroupNew
+			oldStats = newMessageStats()
+		}
+		if !inNew {
+			g.Status = GroupVanished
+			newStats = newMessageStats()
+		}
+		g.Changes = compareMetrics(oldStats, newStats, metrics)
+		diffs = append(diffs, g)
+	}
+	return
+}
+
+// compareMetrics compares the scalar metrics of two groups of stats
+func compareMetrics(old, cur *MessageStats, metrics []string) (changes []MetricChange) {
+	changes = []MetricChange{}
+	oldMetrics := GetStatsSummary("", old).Metrics
+	newMetrics := GetStatsSummary("", cur).Metrics
+	for _, m := range metrics {
+		o, ok := oldMetrics[m]
+		if !ok {
+			continue
+		}
+		n := newMetrics[m]
+		changes = append(changes, MetricChange{Metric: m, Old: o, New: n, Change: n - o})
+	}
+	return
+}
+
+// signedFloatStr formats a float with a + sign if it is positive
+func signedFloatStr(f float64, decimals int) string {
+	if f > 0 {
+		return "+" + floatStr(f, decimals)
+	}
+	return floatStr(f, decimals)
+}
+
+func joinOrNone(words []string) string {
+	if len(words) == 0 {
+		return "none"
+	}
+	s := words[0]
+	for _, w := range words[1:] {
+		s += ", " + w
+	}
+	return s
+}
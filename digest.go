@@ -0,0 +1,148 @@
+package slackanalytics
+
+import (
+	"io"
+	"math"
+	"strings"
+	"text/template"
+)
+
+const (
+	// digestAmount is the number of rising and
+	// falling words a digest holds each way
+	digestAmount = 5
+	// digestToneMonths is the number of months in the tone trend
+	digestToneMonths = 6
+)
+
+var (
+	digestFuncs = template.FuncMap{
+		"float":  shortFloatStr,
+		"signed": signedStr,
+		"md":     escapeMarkdown,
+		"top":    topRows,
+	}
+
+	// DefaultDigestTemplate is the Markdown digest template
+	// used by RenderMarkdownDigest when none is given
+	DefaultDigestTemplate = template.Must(template.New("digest.md").Funcs(digestFuncs).ParseFS(templateFS, "templates/digest.md"))
+
+	markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "|", `\|`, "#", `\#`)
+)
+
+// Digest is the data a digest template is executed with: the report,
+// the latest and previous months, the tone of the last months, how
+// the main metrics changed since the previous month and the words
+// rising and falling most in the latest month
+type Digest struct {
+	Report
+	Month         string
+	PreviousMonth string
+	Current       *MessageStats
+	Previous      *MessageStats
+	ToneTrend     []ReportPeriod
+	Changes       []DigestChange
+	Trends        Trends
+}
+
+// DigestChange is a metric of the latest month next to the previous one
+type DigestChange struct {
+	Metric   string
+	Previous float64
+	Current  float64
+	Change   float64
+}
+
+// ParseDigestTemplate reads a custom text/template for the digest; it is
+// executed with a Digest and can use the functions float (at most one decimal),
+// signed (with a + for positive numbers), md (escape Markdown) and top
+// (the first n report rows)
+func ParseDigestTemplate(filePath string) (*template.Template, error) {
+	name := filePath[strings.LastIndexAny(filePath, `/\`)+1:]
+	return template.New(name).Funcs(digestFuncs).ParseFiles(filePath)
+}
+
+// BuildDigest adds the monthly comparisons of a digest to a report
+func BuildDigest(r Report) (d Digest) {
+	d = Digest{Report: r, Trends: Trends{Rising: []Trend{}, Falling: []Trend{}}}
+	n := len(r.Monthly)
+	if n == 0 {
+		return
+	}
+	d.Month = r.Monthly[n-1].Period
+	d.Current = r.Monthly[n-1].Stats
+	start := n - digestToneMonths
+	if start < 0 {
+		start = 0
+	}
+	d.ToneTrend = r.Monthly[start:]
+	if n < 2 {
+		return
+	}
+	d.PreviousMonth = r.Monthly[n-2].Period
+	d.Previous = r.Monthly[n-2].Stats
+	metrics := []struct {
+		name              string
+		previous, current float64
+	}{
+		{"Messages", float64(d.Previous.NumMessages), float64(d.Current.NumMessages)},
+		{"Words", float64(d.Previous.NumWords), float64(d.Current.NumWords)},
+		{"Emojis", float64(d.Previous.NumEmojis), float64(d.Current.NumEmojis)},
+		{"Tone", d.Previous.Scores.Tone, d.Current.Scores.Tone},
+		{"Clout", d.Previous.Scores.Clout, d.Current.Scores.Clout},
+		{"Analytic", d.Previous.Scores.Analytic, d.Current.Scores.Analytic},
+	}
+	for _, m := range metrics {
+		d.Changes = append(d.Changes, DigestChange{
+			Metric:   m.name,
+			Previous: m.previous,
+			Current:  m.current,
+			Change:   m.current - m.previous,
+		})
+	}
+	periods := make(map[string]*MessageStats)
+	for _, p := range r.Monthly {
+		periods[p.Period] = p.Stats
+	}
+	opt := DefaultTrendOptions()
+	opt.Period = d.Month
+	opt.Amount = digestAmount
+	d.Trends = GetTermTrends(periods, opt)
+	return
+}
+
+// RenderMarkdownDigest writes a short Markdown digest of a report
+// (see BuildDigest) with tmpl, or DefaultDigestTemplate if it is nil
+func RenderMarkdownDigest(w io.Writer, r Report, tmpl *template.Template) error {
+	if tmpl == nil {
+		tmpl = DefaultDigestTemplate
+	}
+	return tmpl.Execute(w, BuildDigest(r))
+}
+
+// shortFloatStr formats a number with at most one decimal
+func shortFloatStr(f float64) string {
+	return floatStr(math.Round(f*10)/10, -1)
+}
+
+// signedStr formats a number with at most one
+// decimal and a + sign if it is positive
+func signedStr(f float64) string {
+	if f > 0 {
+		return "+" + shortFloatStr(f)
+	}
+	return shortFloatStr(f)
+}
+
+// escapeMarkdown escapes the characters Markdown would format
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// topRows returns the first n rows
+func topRows(n int, rows []ReportRow) []ReportRow {
+	if len(rows) > n {
+		return rows[:n]
+	}
+	return rows
+}
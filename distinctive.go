@@ -0,0 +1,131 @@
+package slackanalytics
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DistinctTFIDF scores words by term frequency times
+	// inverse document frequency across all documents
+	DistinctTFIDF = "tfidf"
+	// DistinctLogOdds scores words by the z-scored log-odds ratio
+	// of a document against the rest of the workspace, using an
+	// informative Dirichlet prior (Monroe et al., 2008)
+	DistinctLogOdds = "log-odds"
+)
+
+var (
+	// LogOddsPriorSize is the total pseudo-count of the
+	// workspace-wide prior used by DistinctLogOdds
+	LogOddsPriorSize = 1000.0
+)
+
+// WordScore holds a word along with its count
+// in a document and its distinctiveness score
+type WordScore struct {
+	Word  string  `json:"word"`
+	Count int     `json:"count"`
+	Score float64 `json:"score"`
+}
+
+// GetDistinctiveWords treats each word stats (e.g. SlackStats.UserStats or
+// ChannelStats) as a document and returns, per document key, the amount words
+// most typical of it according to method (DistinctTFIDF or DistinctLogOdds);
+// common words (those used as stopwords of the language of their messages)
+// are skipped if includeCommon is false
+func GetDistinctiveWords(docs map[string]*WordStats, method string, amount int, includeCommon bool) map[string][]WordScore {
+	wordCountMaps := make(map[string]map[string]int)
+	stopwords := make(map[string]func(string) bool)
+	for key, ws := range docs {
+		wordCountMaps[key] = ws.WordCountMap
+		stopwords[key] = ws.isStopword
+	}
+	return getDistinctiveWords(wordCountMaps, stopwords, method, amount, includeCommon)
+}
+
+// GetDistinctiveMsgWords is GetDistinctiveWords for message stats
+// (e.g. SlackMessageStats.UserStats or MonthlyStats)
+func GetDistinctiveMsgWords(docs map[string]*MessageStats, method string, amount int, includeCommon bool) map[string][]WordScore {
+	wordCountMaps := make(map[string]map[string]int)
+	stopwords := make(map[string]func(string) bool)
+	for key, ms := range docs {
+		wordCountMaps[key] = ms.WordCountMap
+		stopwords[key] = ms.isStopword
+	}
+	return getDistinctiveWords(wordCountMaps, stopwords, method, amount, includeCommon)
+}
+
+// getDistinctiveWords scores every word of every document and keeps the
+// amount best scoring words per document, skipping the words stopwords
+// reports as common in a document if includeCommon is false
+func getDistinctiveWords(docs map[string]map[string]int, stopwords map[string]func(string) bool, method string, amount int, includeCommon bool) (distinctive map[string][]WordScore) {
+	distinctive = make(map[string][]WordScore)
+	totals := make(map[string]int)
+	docFreqs := make(map[string]int)
+	total := 0
+	numDocs := 0
+	for _, wcm := range docs {
+		if len(wcm) == 0 {
+			continue
+		}
+		numDocs += 1
+		for w, c := range wcm {
+			totals[w] += c
+			docFreqs[w] += 1
+			total += c
+		}
+	}
+	for key, wcm := range docs {
+		docTotal := 0
+		for _, c := range wcm {
+			docTotal += c
+		}
+		if docTotal == 0 {
+			continue
+		}
+		scores := []WordScore{}
+		for w, c := range wcm {
+			if !includeCommon && stopwords[key](w) {
+				continue
+			}
+			var score float64
+			if method == DistinctTFIDF {
+				score = float64(c) / float64(docTotal) * math.Log(float64(numDocs)/float64(docFreqs[w]))
+			} else {
+				score = logOddsZScore(c, docTotal, totals[w]-c, total-docTotal, totals[w], total)
+			}
+			scores = append(scores, WordScore{Word: w, Count: c, Score: score})
+		}
+		sort.SliceStable(scores, func(i, j int) bool {
+			if scores[i].Score == scores[j].Score {
+				return scores[i].Word < scores[j].Word
+			}
+			return scores[i].Score > scores[j].Score
+		})
+		if len(scores) > amount {
+			scores = scores[:amount]
+		}
+		distinctive[key] = scores
+	}
+	return
+}
+
+// logOddsZScore computes the z-scored log-odds ratio of a word between a
+// document and the rest of the corpus, smoothed by the corpus frequency
+func logOddsZScore(docCount, docTotal, restCount, restTotal, corpusCount, corpusTotal int) float64 {
+	if corpusTotal == 0 {
+		return 0
+	}
+	alpha := LogOddsPriorSize * float64(corpusCount) / float64(corpusTotal)
+	yi := float64(docCount) + alpha
+	yj := float64(restCount) + alpha
+	ni := float64(docTotal) + LogOddsPriorSize
+	nj := float64(restTotal) + LogOddsPriorSize
+	if ni <= yi || nj <= yj {
+		return 0
+	}
+	delta := math.Log(yi/(ni-yi)) - math.Log(yj/(nj-yj))
+	variance := 1/yi + 1/yj
+	return delta / math.Sqrt(variance)
+}
@@ -0,0 +1,187 @@
+package slackanalytics
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	emojiRegexp     = regexp.MustCompile(`:[-+_a-zA-Z0-9']+:(?::skin-tone-[1-6]:)?`)
+	emojiWordRegexp = regexp.MustCompile(`^:[-+_a-zA-Z0-9']+:(?::skin-tone-[1-6]:)?$`)
+	skinToneRegexp  = regexp.MustCompile(`::skin-tone-[1-6]:$`)
+
+	// EmojiShortcodes maps Unicode emoji (without skin tone modifiers
+	// or variation selectors) to their canonical Slack shortcode name
+	EmojiShortcodes = map[string]string{
+		"👍": "+1", "👎": "-1", "😀": "grinning", "😃": "smiley", "😄": "smile",
+		"😁": "grin", "😆": "laughing", "😅": "sweat_smile", "😂": "joy", "🤣": "rolling_on_the_floor_laughing",
+		"🙂": "slightly_smiling_face", "🙃": "upside_down_face", "😉": "wink", "😊": "blush", "😇": "innocent",
+		"😍": "heart_eyes", "🤩": "star-struck", "😘": "kissing_heart", "😎": "sunglasses", "🥳": "partying_face",
+		"🤗": "hugging_face", "🤔": "thinking_face", "😐": "neutral_face", "😑": "expressionless", "😶": "no_mouth",
+		"🙄": "face_with_rolling_eyes", "😏": "smirk", "😌": "relieved", "😔": "pensive", "😕": "confused",
+		"🙁": "slightly_frowning_face", "☹": "white_frowning_face", "😟": "worried", "😢": "cry", "😭": "sob",
+		"😤": "triumph", "😠": "angry", "😡": "rage", "🤬": "face_with_symbols_on_mouth", "😱": "scream",
+		"😨": "fearful", "😰": "cold_sweat", "😥": "disappointed_relieved", "😞": "disappointed", "😩": "weary",
+		"😫": "tired_face", "🤯": "exploding_head", "🤢": "nauseated_face", "🤮": "face_vomiting", "💩": "hankey",
+		"💀": "skull", "😬": "grimacing", "😴": "sleeping", "😮": "open_mouth", "😲": "astonished",
+		"🤦": "face_palm", "🤷": "shrug", "🙏": "pray", "👏": "clap", "🙌": "raised_hands",
+		"👌": "ok_hand", "💪": "muscle", "👋": "wave", "👀": "eyes", "❤": "heart",
+		"💔": "broken_heart", "💯": "100", "🔥": "fire", "🎉": "tada", "✨": "sparkles",
+		"🚀": "rocket", "⭐": "star", "🏆": "trophy", "✅": "white_check_mark", "✔": "heavy_check_mark",
+		"❌": "x", "⚠": "warning", "🚨": "rotating_light", "🐛": "bug", "💡": "bulb",
+		"😿": "crying_cat_face", "🥲": "smiling_face_with_tear", "🥺": "pleading_face", "😒": "unamused", "😳": "flushed",
+	}
+
+	// EmojiAliases maps alternative shortcode names
+	// to the canonical Slack shortcode name
+	EmojiAliases = map[string]string{
+		"thumbsup":               "+1",
+		"thumbsdown":             "-1",
+		"simple_smiley":          "slightly_smiling_face",
+		"satisfied":              "laughing",
+		"party_popper":           "tada",
+		"hugs":                   "hugging_face",
+		"thinking":               "thinking_face",
+		"roll_eyes":              "face_with_rolling_eyes",
+		"rofl":                   "rolling_on_the_floor_laughing",
+		"facepalm":               "face_palm",
+		"poop":                   "hankey",
+		"shit":                   "hankey",
+		"red_heart":              "heart",
+		"check":                  "white_check_mark",
+		"heavy_multiplication_x": "x",
+		"frowning_face":          "white_frowning_face",
+		"pout":                   "rage",
+		"cursing_face":           "face_with_symbols_on_mouth",
+		"star_struck":            "star-struck",
+		"partying":               "partying_face",
+		"vomiting_face":          "face_vomiting",
+		"light_bulb":             "bulb",
+	}
+
+	// EmojiSentiment gives the sentiment polarity (+1 or -1)
+	// of a canonical shortcode; neutral emojis are left out
+	EmojiSentiment = map[string]int{
+		"+1": 1, "-1": -1, "grinning": 1, "smiley": 1, "smile": 1,
+		"grin": 1, "laughing": 1, "sweat_smile": 1, "joy": 1, "rolling_on_the_floor_laughing": 1,
+		"slightly_smiling_face": 1, "wink": 1, "blush": 1, "innocent": 1, "heart_eyes": 1,
+		"star-struck": 1, "kissing_heart": 1, "sunglasses": 1, "partying_face": 1, "hugging_face": 1,
+		"face_with_rolling_eyes": -1, "pensive": -1, "confused": -1, "slightly_frowning_face": -1, "white_frowning_face": -1,
+		"worried": -1, "cry": -1, "sob": -1, "angry": -1, "rage": -1,
+		"face_with_symbols_on_mouth": -1, "scream": -1, "fearful": -1, "cold_sweat": -1, "disappointed_relieved": -1,
+		"disappointed": -1, "weary": -1, "tired_face": -1, "nauseated_face": -1, "face_vomiting": -1,
+		"hankey": -1, "face_palm": -1, "pray": 1, "clap": 1, "raised_hands": 1,
+		"ok_hand": 1, "muscle": 1, "heart": 1, "broken_heart": -1, "100": 1,
+		"fire": 1, "tada": 1, "sparkles": 1, "rocket": 1, "star": 1,
+		"trophy": 1, "white_check_mark": 1, "heavy_check_mark": 1, "x": -1, "rotating_light": -1,
+		"crying_cat_face": -1, "pleading_face": -1, "unamused": -1, "grimacing": -1,
+	}
+)
+
+// ExtractEmojis takes in message text and returns all emojis found in it
+// (both :shortcode: and Unicode emojis), normalized with NormalizeEmoji
+func ExtractEmojis(text string) (emojis []string) {
+	_, emojis = extractEmojis(text)
+	return
+}
+
+// NormalizeEmoji takes in a :shortcode: or Unicode emoji and returns the
+// canonical :shortcode: with skin tones folded and aliases resolved;
+// Unicode emojis without a known shortcode are returned without modifiers
+func NormalizeEmoji(e string) string {
+	if strings.HasPrefix(e, ":") && strings.HasSuffix(e, ":") && len(e) > 2 {
+		name := strings.ToLower(strings.Trim(skinToneRegexp.ReplaceAllString(e, ":"), ":"))
+		if canonical, ok := EmojiAliases[name]; ok {
+			name = canonical
+		}
+		return ":" + name + ":"
+	}
+	stripped := strings.Map(func(r rune) rune {
+		if isEmojiModifier(r) {
+			return -1
+		}
+		return r
+	}, e)
+	if name, ok := EmojiShortcodes[stripped]; ok {
+		return ":" + name + ":"
+	}
+	return stripped
+}
+
+// GetEmojiTone calculates the tone of a slice of emojis
+// (+1 for positive and -1 for negative emojis)
+func GetEmojiTone(emojis []string) (tone int) {
+	for _, e := range emojis {
+		tone += EmojiSentiment[strings.Trim(NormalizeEmoji(e), ":")]
+	}
+	return
+}
+
+// extractEmojis replaces every emoji in text with a space and
+// returns the remaining text along with the normalized emojis
+func extractEmojis(text string) (remaining string, emojis []string) {
+	text = emojiRegexp.ReplaceAllStringFunc(text, func(s string) string {
+		emojis = append(emojis, NormalizeEmoji(s))
+		return " "
+	})
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !isEmojiRune(r) {
+			if !isEmojiModifier(r) {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		// gather modifiers, zero width joined emojis and flag pairs
+		seq := []rune{r}
+		for i+1 < len(runes) {
+			next := runes[i+1]
+			if isEmojiModifier(next) {
+				seq = append(seq, next)
+				i++
+				continue
+			}
+			if next == '\u200d' && i+2 < len(runes) && isEmojiRune(runes[i+2]) {
+				seq = append(seq, next, runes[i+2])
+				i += 2
+				continue
+			}
+			if isRegionalIndicator(r) && len(seq) == 1 && isRegionalIndicator(next) {
+				seq = append(seq, next)
+				i++
+				continue
+			}
+			break
+		}
+		emojis = append(emojis, NormalizeEmoji(string(seq)))
+		b.WriteRune(' ')
+	}
+	remaining = b.String()
+	return
+}
+
+// isEmojiRune decides whether a rune
+// is in one of the emoji blocks
+func isEmojiRune(r rune) bool {
+	if isEmojiModifier(r) {
+		return false
+	}
+	return (r >= 0x1F000 && r <= 0x1FAFF) ||
+		(r >= 0x2600 && r <= 0x27BF) ||
+		(r >= 0x2300 && r <= 0x23FF) ||
+		r == 0x2B50 || r == 0x2B55 || r == 0x2B1B || r == 0x2B1C
+}
+
+// isEmojiModifier decides whether a rune is a skin tone
+// modifier or a variation selector
+func isEmojiModifier(r rune) bool {
+	return (r >= 0x1F3FB && r <= 0x1F3FF) || r == 0xFE0F || r == 0xFE0E
+}
+
+// isRegionalIndicator decides whether a rune is one
+// half of a flag emoji
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
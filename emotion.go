@@ -0,0 +1,110 @@
+package slackanalytics
+
+import (
+	"strings"
+)
+
+var (
+	// Emotions lists Plutchik's eight basic emotions
+	Emotions = []string{"joy", "trust", "fear", "surprise", "sadness", "disgust", "anger", "anticipation"}
+
+	// EmotionCategories maps each emotion to the
+	// word categories that roll up into it
+	EmotionCategories = map[string][]string{
+		"joy":          {"joy", "cheerfulness", "contentment", "celebration", "fun", "zest", "love", "affection"},
+		"trust":        {"trust", "sympathy", "politeness", "friends", "giving"},
+		"fear":         {"fear", "horror", "nervousness", "timidity"},
+		"surprise":     {"surprise", "confusion"},
+		"sadness":      {"sadness", "suffering", "disappointment", "neglect", "torment", "pain", "shame"},
+		"disgust":      {"disgust", "ugliness", "ridicule"},
+		"anger":        {"anger", "rage", "hate", "aggression", "irritability", "exasperation", "envy"},
+		"anticipation": {"anticipation", "optimism"},
+	}
+
+	// EmojiEmotions maps a canonical emoji shortcode to an emotion
+	EmojiEmotions = map[string]string{
+		"grinning": "joy", "smiley": "joy", "smile": "joy", "grin": "joy", "laughing": "joy",
+		"joy": "joy", "rolling_on_the_floor_laughing": "joy", "heart_eyes": "joy", "heart": "joy", "tada": "joy",
+		"partying_face": "joy", "+1": "trust", "pray": "trust", "handshake": "trust", "hugging_face": "trust",
+		"ok_hand": "trust", "scream": "fear", "fearful": "fear", "cold_sweat": "fear", "grimacing": "fear",
+		"open_mouth": "surprise", "astonished": "surprise", "exploding_head": "surprise", "flushed": "surprise", "cry": "sadness",
+		"sob": "sadness", "disappointed": "sadness", "pensive": "sadness", "broken_heart": "sadness", "pleading_face": "sadness",
+		"nauseated_face": "disgust", "face_vomiting": "disgust", "hankey": "disgust", "unamused": "disgust", "face_with_rolling_eyes": "disgust",
+		"rage": "anger", "angry": "anger", "face_with_symbols_on_mouth": "anger", "triumph": "anger", "-1": "anger",
+		"eyes": "anticipation", "thinking_face": "anticipation", "crossed_fingers": "anticipation", "hourglass_flowing_sand": "anticipation", "rocket": "anticipation",
+	}
+
+	// categoryEmotions maps each word category to the emotions
+	// it rolls up into; it is built once from EmotionCategories
+	categoryEmotions = make(map[string][]string)
+)
+
+func init() {
+	for emotion, cats := range EmotionCategories {
+		for _, cat := range cats {
+			categoryEmotions[cat] = append(categoryEmotions[cat], emotion)
+		}
+	}
+}
+
+// GetEmotions takes in a slice of words and emojis and returns how
+// many of them express each emotion; a word counts once per emotion
+func GetEmotions(words []string, emojis []string) (emotions map[string]int) {
+	emotions = newEmotionCounts()
+	for _, w := range words {
+		for _, e := range GetWordEmotions(GetCategories(strings.ToLower(w))) {
+			emotions[e] += 1
+		}
+	}
+	for _, e := range emojis {
+		if emotion, ok := EmojiEmotions[strings.Trim(NormalizeEmoji(e), ":")]; ok {
+			emotions[emotion] += 1
+		}
+	}
+	return
+}
+
+// GetMessageEmotions returns the emotion vector of a single message
+func GetMessageEmotions(m Message) map[string]int {
+	words, emojis := TokenizeMessage(m)
+	return GetEmotions(words, emojis)
+}
+
+// GetWordEmotions takes in the categories of a word
+// and returns the distinct emotions they roll up into
+func GetWordEmotions(categories []string) (emotions []string) {
+	for _, cat := range categories {
+		for _, e := range categoryEmotions[cat] {
+			if !inList(e, emotions) {
+				emotions = append(emotions, e)
+			}
+		}
+	}
+	return
+}
+
+// GetEmotionShares takes in emotion counts and returns each
+// emotion's share of all emotional expressions (0-1)
+func GetEmotionShares(emotionCounts map[string]int) (shares map[string]float64) {
+	shares = make(map[string]float64)
+	total := 0
+	for _, c := range emotionCounts {
+		total += c
+	}
+	for _, e := range Emotions {
+		if total == 0 {
+			shares[e] = 0
+			continue
+		}
+		shares[e] = float64(emotionCounts[e]) / float64(total)
+	}
+	return
+}
+
+func newEmotionCounts() map[string]int {
+	emotions := make(map[string]int)
+	for _, e := range Emotions {
+		emotions[e] = 0
+	}
+	return emotions
+}
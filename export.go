@@ -1,14 +1,215 @@
 package slackanalytics
 
 import (
+	"encoding/csv"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
-func ExportSlackMessageStats(s SlackMessageStats) {
-	file, _ := json.MarshalIndent(s, "", "	")
-	now := time.Now().Unix()
-	_ = ioutil.WriteFile("./dashboard/"+strconv.FormatInt(now, 10)+".json", file, 0644)
+const (
+	// ExportJSON writes the stats as a single indented JSON object
+	ExportJSON = "json"
+	// ExportNDJSON writes one JSON record per line (see StatsRecord)
+	ExportNDJSON = "ndjson"
+	// ExportCSV writes one row of scalar metrics per record
+	ExportCSV = "csv"
+
+	// ExportStdout is the export path that writes to stdout
+	ExportStdout = "-"
+)
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []string{ExportJSON, ExportNDJSON, ExportCSV}
+
+// ExportOptions configures ExportSlackMessageStatsWithOptions: stats are
+// written to Writer if set, else to Path, which is either ExportStdout, a
+// file or a directory (an existing one or a path ending with a slash) in
+// which a <unix time>.<format> file is created
+type ExportOptions struct {
+	Path   string
+	Writer io.Writer
+	Format string
+}
+
+// StatsRecord is the stats of a single group of messages: Kind is
+// "all", "user", "channel", "day", "month" or "language" and Key is
+// the user id, channel, day, month or language the stats are about
+type StatsRecord struct {
+	Kind  string        `json:"kind"`
+	Key   string        `json:"key"`
+	Stats *MessageStats `json:"stats"`
+}
+
+// DefaultExportOptions writes JSON files to the dashboard folder
+func DefaultExportOptions() ExportOptions {
+	return ExportOptions{
+		Path:   "./dashboard/",
+		Format: ExportJSON,
+	}
+}
+
+// ExportSlackMessageStats writes the stats as JSON
+// to a new file in the dashboard folder
+func ExportSlackMessageStats(s SlackMessageStats) error {
+	return ExportSlackMessageStatsWithOptions(s, DefaultExportOptions())
+}
+
+// ExportSlackMessageStatsWithOptions writes the stats in opt.Format to
+// opt.Writer or opt.Path, creating missing directories on the way
+func ExportSlackMessageStatsWithOptions(s SlackMessageStats, opt ExportOptions) (err error) {
+	if opt.Format == "" {
+		opt.Format = ExportJSON
+	}
+	if !inList(opt.Format, ExportFormats) {
+		return errors.New("unknown export format " + opt.Format)
+	}
+	if opt.Writer != nil {
+		return WriteSlackMessageStats(opt.Writer, s, opt.Format)
+	}
+	if opt.Path == ExportStdout {
+		return WriteSlackMessageStats(os.Stdout, s, opt.Format)
+	}
+	filePath, err := exportFilePath(opt.Path, opt.Format)
+	if err != nil {
+		return
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return
+	}
+	err = WriteSlackMessageStats(file, s, opt.Format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
+
+// WriteSlackMessageStats writes the stats to w in the given format
+func WriteSlackMessageStats(w io.Writer, s SlackMessageStats, format string) (err error) {
+	switch format {
+	case ExportJSON:
+		var statsBytes []byte
+		statsBytes, err = json.MarshalIndent(s, "", "	")
+		if err != nil {
+			return
+		}
+		_, err = w.Write(append(statsBytes, '\n'))
+	case ExportNDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range GetStatsRecords(s) {
+			if err = enc.Encode(r); err != nil {
+				return
+			}
+		}
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(append([]string{"kind", "key"}, StatsColumns...))
+		for _, r := range GetStatsRecords(s) {
+			cw.Write(append([]string{r.Kind, r.Key}, statsRow(r.Stats)...))
+		}
+		cw.Flush()
+		err = cw.Error()
+	default:
+		err = errors.New("unknown export format " + format)
+	}
+	return
+}
+
+// GetStatsRecords flattens the stats into records: the overall stats
+// first, then users, channels, days, months and languages in key order
+func GetStatsRecords(s SlackMessageStats) (records []StatsRecord) {
+	if s.AllStats != nil {
+		records = append(records, StatsRecord{Kind: "all", Stats: s.AllStats})
+	}
+	groups := []struct {
+		kind  string
+		stats map[string]*MessageStats
+	}{
+		{"user", s.UserStats},
+		{"channel", s.ChannelStats},
+		{"day", s.DailyStats},
+		{"month", s.MonthlyStats},
+		{"language", s.LanguageStats},
+	}
+	for _, g := range groups {
+		for _, k := range sortedStatsKeys(g.stats) {
+			records = append(records, StatsRecord{Kind: g.kind, Key: k, Stats: g.stats[k]})
+		}
+	}
+	return
+}
+
+// StatsColumns are the names of the scalar metrics written
+// for each record by the CSV export, in order
+var StatsColumns = []string{
+	"num_messages", "num_scored_messages", "num_words", "num_emojis", "total_text_length",
+	"avg_word_length", "avg_words_per_msg", "avg_emojis_per_msg",
+	"avg_clout_per_msg", "avg_tone_per_msg", "avg_analytic_per_msg",
+	"clout", "tone", "analytic", "clout_percent", "tone_percent", "analytic_percent",
+	"flesch_reading_ease", "flesch_kincaid_grade", "gunning_fog", "type_token_ratio", "mtld",
+	"num_code_messages", "code_share", "num_links", "link_share",
+}
+
+// statsValues returns the scalar metrics of message stats in StatsColumns
+// order; counts are ints and all other metrics float64s
+func statsValues(ms *MessageStats) []interface{} {
+	return []interface{}{
+		ms.NumMessages, ms.NumScoredMessages, ms.NumWords, ms.NumEmojis, ms.TotalTextLength,
+		ms.AvgWordLength, ms.AvgWordsPerMsg, ms.AvgEmojisPerMsg,
+		ms.AvgCloutPerMsg, ms.AvgTonePerMsg, ms.AvgAnalyticPerMsg,
+		ms.Scores.Clout, ms.Scores.Tone, ms.Scores.Analytic,
+		ms.Scores.CloutPercent, ms.Scores.TonePercent, ms.Scores.AnalyticPercent,
+		ms.Readability.FleschReadingEase, ms.Readability.FleschKincaidGrade, ms.Readability.GunningFog,
+		ms.Readability.TypeTokenRatio, ms.Readability.MTLD,
+		ms.Code.NumCodeMessages, ms.Code.CodeShare, ms.Links.NumLinks, ms.Links.LinkShare,
+	}
+}
+
+// statsRow returns the scalar metrics of message stats in StatsColumns order
+func statsRow(ms *MessageStats) (row []string) {
+	for _, v := range statsValues(ms) {
+		switch v := v.(type) {
+		case int:
+			row = append(row, strconv.Itoa(v))
+		case float64:
+			row = append(row, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	return
+}
+
+func intCells(values ...int) (cells []string) {
+	for _, v := range values {
+		cells = append(cells, strconv.Itoa(v))
+	}
+	return
+}
+
+func floatCells(values ...float64) (cells []string) {
+	for _, v := range values {
+		cells = append(cells, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return
+}
+
+// exportFilePath resolves the file to export to, creating its
+// directory; directories get a new <unix time>.<format> file
+func exportFilePath(path, format string) (filePath string, err error) {
+	if path == "" {
+		path = DefaultExportOptions().Path
+	}
+	info, statErr := os.Stat(path)
+	isDir := strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) || (statErr == nil && info.IsDir())
+	filePath = path
+	if isDir {
+		filePath = filepath.Join(path, strconv.FormatInt(time.Now().Unix(), 10)+"."+format)
+	}
+	err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	return
 }
@@ -0,0 +1,97 @@
+package slackanalytics
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strconv"
+)
+
+const (
+	chartWidth  = 720
+	chartHeight = 160
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// reportTemplate renders a self-contained HTML page: styles and
+// SVG charts are inlined so it works offline without a CDN
+var reportTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"float":   func(f float64) string { return floatStr(f, 2) },
+	"percent": func(f float64) string { return floatStr(f*100, 1) + "%" },
+	"chart":   newActivityChart,
+}).ParseFS(templateFS, "templates/report.html"))
+
+// activityChart is an SVG bar chart of the number of messages per period
+type activityChart struct {
+	Width  int
+	Height int
+	Max    int
+	Bars   []chartBar
+	Labels []chartLabel
+}
+
+type chartBar struct {
+	X, Y, Width, Height float64
+	Period              string
+	Count               int
+}
+
+type chartLabel struct {
+	X      float64
+	Anchor string
+	Text   string
+}
+
+// RenderHTMLReport writes a report as a single offline HTML page with
+// overview numbers, user and channel tables, top words and emojis,
+// categories and charts of daily and monthly activity
+func RenderHTMLReport(w io.Writer, r Report) error {
+	return reportTemplate.Execute(w, r)
+}
+
+// newActivityChart lays out one bar per period, scaled to the busiest
+// one, with the first, middle and last periods as axis labels
+func newActivityChart(periods []ReportPeriod) (c activityChart) {
+	c = activityChart{Width: chartWidth, Height: chartHeight}
+	for _, p := range periods {
+		if p.Stats.NumMessages > c.Max {
+			c.Max = p.Stats.NumMessages
+		}
+	}
+	if len(periods) == 0 || c.Max == 0 {
+		return
+	}
+	plotHeight := float64(chartHeight - 20)
+	step := float64(chartWidth) / float64(len(periods))
+	gap := step * 0.15
+	if step < 3 {
+		gap = 0
+	}
+	for i, p := range periods {
+		h := plotHeight * float64(p.Stats.NumMessages) / float64(c.Max)
+		c.Bars = append(c.Bars, chartBar{
+			X:      float64(i)*step + gap/2,
+			Y:      plotHeight - h,
+			Width:  step - gap,
+			Height: h,
+			Period: p.Period,
+			Count:  p.Stats.NumMessages,
+		})
+	}
+	c.Labels = append(c.Labels, chartLabel{X: 0, Anchor: "start", Text: periods[0].Period})
+	if len(periods) > 2 {
+		mid := len(periods) / 2
+		c.Labels = append(c.Labels, chartLabel{X: float64(mid)*step + step/2, Anchor: "middle", Text: periods[mid].Period})
+	}
+	if len(periods) > 1 {
+		c.Labels = append(c.Labels, chartLabel{X: float64(chartWidth), Anchor: "end", Text: periods[len(periods)-1].Period})
+	}
+	return
+}
+
+// String describes the chart for screen readers
+func (c activityChart) String() string {
+	return strconv.Itoa(len(c.Bars)) + " periods, at most " + strconv.Itoa(c.Max) + " messages"
+}
@@ -0,0 +1,189 @@
+package slackanalytics
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// LanguageUndetermined is reported for messages too
+	// short or too ambiguous to detect their language
+	LanguageUndetermined = "und"
+	// languageProfileSize is the number of most frequent
+	// trigrams kept in a language profile
+	languageProfileSize = 300
+	// minLanguageLetters is the minimum number of letters
+	// a text needs before its language is detected
+	minLanguageLetters = 12
+)
+
+var (
+	// ScoredLanguages lists the languages whose messages feed the
+	// english-only lexicon scores (clout, tone, analytic, categories)
+	ScoredLanguages = []string{"en", LanguageUndetermined}
+
+	// Stopwords holds the stopword list of each supported language
+	Stopwords = map[string][]string{
+		"en": CommonWords,
+		"es": []string{"a", "al", "algo", "algunos", "ante", "antes", "aquí", "así", "bien", "cada", "como", "con", "cual", "cuando", "de", "del", "desde", "donde", "dos", "e", "el", "ella", "ellas", "ellos", "en", "entre", "era", "es", "esa", "ese", "eso", "esta", "está", "están", "este", "esto", "estos", "fue", "ha", "hay", "hasta", "la", "las", "le", "les", "lo", "los", "más", "me", "mi", "muy", "nada", "ni", "no", "nos", "nosotros", "o", "otro", "para", "pero", "poco", "por", "porque", "que", "qué", "se", "ser", "si", "sí", "sin", "sobre", "son", "su", "sus", "también", "te", "tengo", "tiene", "todo", "todos", "tu", "tú", "un", "una", "uno", "unos", "y", "ya", "yo"},
+		"de": []string{"aber", "alle", "als", "also", "am", "an", "auch", "auf", "aus", "bei", "bin", "bis", "bist", "da", "das", "dass", "dein", "dem", "den", "der", "des", "die", "dies", "diese", "doch", "du", "durch", "ein", "eine", "einem", "einen", "einer", "er", "es", "für", "gibt", "hab", "habe", "haben", "hat", "hier", "ich", "ihr", "im", "in", "ist", "ja", "jetzt", "kann", "kein", "mal", "man", "mich", "mir", "mit", "muss", "nach", "nicht", "noch", "nur", "ob", "oder", "ohne", "schon", "sehr", "sich", "sie", "sind", "so", "um", "und", "uns", "von", "vor", "war", "was", "weil", "wenn", "wer", "wie", "wir", "wird", "zu", "zum", "zur"},
+	}
+
+	// languageSamples holds sample text used to build
+	// the trigram profile of each supported language
+	languageSamples = map[string]string{
+		"en": "The build is broken again on the main branch and I think it is because of the last merge. " +
+			"Could you take a look when you have a moment? We should probably roll back the deploy before the " +
+			"meeting this afternoon. Thanks for the update, that sounds great to me. Let me know if there is " +
+			"anything I can do to help with the release. I will be out of the office tomorrow, but I can check " +
+			"my messages in the evening. What time works for everyone on Thursday? The customer reported that " +
+			"the page was loading slowly, so we are going to look into the database queries and the cache. " +
+			"Happy birthday! Did anyone see the new design for the dashboard? It looks really nice and clean.",
+		"es": "La compilación está rota otra vez en la rama principal y creo que es por la última fusión. " +
+			"¿Puedes echarle un vistazo cuando tengas un momento? Deberíamos revertir el despliegue antes de la " +
+			"reunión de esta tarde. Gracias por la actualización, me parece muy bien. Avísame si hay algo que " +
+			"pueda hacer para ayudar con la versión. Mañana no estaré en la oficina, pero puedo revisar mis " +
+			"mensajes por la noche. ¿Qué hora os viene bien a todos el jueves? El cliente dijo que la página " +
+			"cargaba muy lento, así que vamos a revisar las consultas de la base de datos y la caché. " +
+			"¡Feliz cumpleaños! ¿Alguien vio el nuevo diseño del panel? Se ve muy bonito y limpio.",
+		"de": "Der Build ist schon wieder auf dem Hauptzweig kaputt und ich glaube, das liegt am letzten Merge. " +
+			"Kannst du dir das anschauen, wenn du einen Moment Zeit hast? Wir sollten das Deployment vor dem " +
+			"Meeting heute Nachmittag zurücksetzen. Danke für das Update, das klingt gut für mich. Sag Bescheid, " +
+			"wenn ich bei dem Release irgendwie helfen kann. Ich bin morgen nicht im Büro, aber ich kann abends " +
+			"meine Nachrichten lesen. Welche Uhrzeit passt allen am Donnerstag? Der Kunde hat gemeldet, dass die " +
+			"Seite sehr langsam lädt, deshalb schauen wir uns die Datenbankabfragen und den Cache an. " +
+			"Alles Gute zum Geburtstag! Hat jemand das neue Design für das Dashboard gesehen? Es sieht wirklich schön aus.",
+	}
+
+	languageProfiles = buildLanguageProfiles()
+)
+
+// DetectLanguage takes in text and returns the ISO 639-1 code of its
+// language by comparing its letter trigrams against the profile of each
+// supported language (out-of-place distance, Cavnar & Trenkle 1994);
+// stopword hits break near ties, and LanguageUndetermined is returned
+// for text with too few letters
+func DetectLanguage(text string) string {
+	text = strings.ToLower(text)
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters += 1
+		}
+	}
+	if letters < minLanguageLetters {
+		return LanguageUndetermined
+	}
+	ranked := rankTrigrams(text)
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	best := LanguageUndetermined
+	bestScore := 0.0
+	for _, lang := range sortedLanguages() {
+		profile := languageProfiles[lang]
+		distance := 0
+		for i, t := range ranked {
+			rank, ok := profile[t]
+			if !ok {
+				distance += languageProfileSize
+				continue
+			}
+			if rank > i {
+				distance += rank - i
+			} else {
+				distance += i - rank
+			}
+		}
+		score := float64(distance) / float64(len(ranked)*languageProfileSize)
+		hits := 0
+		for _, w := range words {
+			if inList(w, Stopwords[lang]) {
+				hits += 1
+			}
+		}
+		if len(words) > 0 {
+			score -= 0.25 * float64(hits) / float64(len(words))
+		}
+		if best == LanguageUndetermined || score < bestScore {
+			best = lang
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// IsStopword determines whether a word is an English stopword; word
+// counts pooled over messages are filtered with the English list, the
+// language of the lexicons, so that English words such as "die", "war"
+// or "also" are not dropped for being stopwords in another language
+func IsStopword(word string) bool {
+	return IsStopwordIn(word, "en")
+}
+
+// IsStopwordIn determines whether a word is a stopword of a language;
+// undetermined and unsupported languages use the English list
+func IsStopwordIn(word, lang string) bool {
+	words, ok := Stopwords[lang]
+	if !ok {
+		words = Stopwords["en"]
+	}
+	return inList(strings.ToLower(word), words)
+}
+
+// isScoredLanguage determines whether messages in a language
+// feed the english-only lexicon scores
+func isScoredLanguage(lang string) bool {
+	return inList(lang, ScoredLanguages)
+}
+
+// buildLanguageProfiles ranks the trigrams of every language sample
+func buildLanguageProfiles() map[string]map[string]int {
+	profiles := make(map[string]map[string]int)
+	for lang, sample := range languageSamples {
+		profile := make(map[string]int)
+		for i, t := range rankTrigrams(strings.ToLower(sample)) {
+			if i >= languageProfileSize {
+				break
+			}
+			profile[t] = i
+		}
+		profiles[lang] = profile
+	}
+	return profiles
+}
+
+// rankTrigrams returns the letter trigrams of text (words padded
+// with underscores) sorted by frequency descending
+func rankTrigrams(text string) (ranked []string) {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		runes := []rune("_" + w + "_")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])] += 1
+		}
+	}
+	for t := range counts {
+		ranked = append(ranked, t)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if counts[ranked[i]] == counts[ranked[j]] {
+			return ranked[i] < ranked[j]
+		}
+		return counts[ranked[i]] > counts[ranked[j]]
+	})
+	return
+}
+
+// sortedLanguages returns the supported languages in a stable order
+func sortedLanguages() (langs []string) {
+	for lang := range languageProfiles {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return
+}
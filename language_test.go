@@ -0,0 +1,57 @@
+package slackanalytics
+
+import "testing"
+
+func TestIsStopwordIn(t *testing.T) {
+	tests := []struct {
+		word, lang string
+		want       bool
+	}{
+		{"the", "en", true},
+		{"The", "und", true},
+		{"die", "en", false},
+		{"war", "en", false},
+		{"also", "en", false},
+		{"die", "de", true},
+		{"hay", "es", true},
+		{"hay", "de", false},
+	}
+	for _, tt := range tests {
+		if got := IsStopwordIn(tt.word, tt.lang); got != tt.want {
+			t.Errorf("IsStopwordIn(%q, %q) = %v, want %v", tt.word, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestTopWordsSkipStopwordsOfMessageLanguage(t *testing.T) {
+	messages := []Message{
+		{User: "U1", Text: "Ich glaube, die Datenbank ist langsam und der Server ist überlastet", TimeStamp: "1600000000.000100"},
+		{User: "U1", Text: "Die Datenbank ist wieder schnell und ich bin froh, dass der Server läuft", TimeStamp: "1600000100.000100"},
+		{User: "U2", Text: "The plants will die if nobody waters them this week", TimeStamp: "1600000200.000100"},
+		{User: "U2", Text: "Those plants die every summer because the office is too hot", TimeStamp: "1600000300.000100"},
+		{User: "U2", Text: "We should die the plants blue, or die trying to keep them alive", TimeStamp: "1600000400.000100"},
+	}
+	s := AnalyzeMessages(messages)
+	de, ok := s.LanguageStats["de"]
+	if !ok {
+		t.Fatalf("no German messages detected in %v", s.LanguageStats)
+	}
+	for _, wc := range GetTopReportWords(de, 50) {
+		if IsStopwordIn(wc.Word, "de") {
+			t.Errorf("German top words include the stopword %q", wc.Word)
+		}
+	}
+	found := false
+	for _, wc := range GetTopReportWords(s.LanguageStats["en"], 50) {
+		found = found || wc.Word == "die"
+	}
+	if !found {
+		t.Error(`English top words drop "die", a stopword in German only`)
+	}
+	channels := []*Channel{{Id: "C1", Name: "general", Messages: messages}}
+	for _, wc := range GetTopStatsWords(GetSlackStats(nil, channels).AllStats, 50, false) {
+		if inList(wc.Word, []string{"und", "ich", "ist", "der"}) {
+			t.Errorf("top words include the German stopword %q", wc.Word)
+		}
+	}
+}
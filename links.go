@@ -0,0 +1,215 @@
+package slackanalytics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// LinkClassOther is the class of links matching no rule
+	LinkClassOther = "other"
+)
+
+var (
+	slackLinkRegexp = regexp.MustCompile(`<(https?://[^|>\s]+)(?:\|([^>]*))?>`)
+	bareLinkRegexp  = regexp.MustCompile(`https?://[^\s<>|]+`)
+
+	// DefaultLinkRules classifies links to common hosts; rules are
+	// matched in order so more specific rules come first
+	DefaultLinkRules = []LinkRule{
+		{Domain: "github.com", PathContains: "/issues", Class: "ticket"},
+		{Domain: "gitlab.com", PathContains: "/issues", Class: "ticket"},
+		{Domain: "atlassian.net", PathContains: "/browse/", Class: "ticket"},
+		{Domain: "atlassian.net", PathContains: "/wiki", Class: "docs"},
+		{Domain: "linear.app", Class: "ticket"},
+		{Domain: "github.com", Class: "code"},
+		{Domain: "gitlab.com", Class: "code"},
+		{Domain: "bitbucket.org", Class: "code"},
+		{Domain: "sourcegraph.com", Class: "code"},
+		{Domain: "docs.google.com", Class: "docs"},
+		{Domain: "drive.google.com", Class: "docs"},
+		{Domain: "notion.so", Class: "docs"},
+		{Domain: "notion.site", Class: "docs"},
+		{Domain: "readthedocs.io", Class: "docs"},
+		{Domain: "figma.com", Class: "design"},
+		{Domain: "youtube.com", Class: "video"},
+		{Domain: "youtu.be", Class: "video"},
+		{Domain: "vimeo.com", Class: "video"},
+		{Domain: "loom.com", Class: "video"},
+		{Domain: "zoom.us", Class: "meeting"},
+		{Domain: "meet.google.com", Class: "meeting"},
+		{Domain: "slack.com", Class: "slack"},
+	}
+
+	// LinkRules are the rules used to classify extracted links;
+	// replace them with LoadLinkRules to use custom rules
+	LinkRules = DefaultLinkRules
+)
+
+// LinkRule assigns Class to links whose host is Domain or one of its
+// subdomains and, if PathContains is set, whose path contains it
+type LinkRule struct {
+	Domain       string `json:"domain"`
+	PathContains string `json:"path_contains"`
+	Class        string `json:"class"`
+}
+
+// Link is a link shared in a message, normalized to a lowercase
+// domain (without www.) and a path without query or fragment
+type Link struct {
+	URL    string
+	Domain string
+	Path   string
+	Label  string
+	Class  string
+}
+
+// LinkStats holds statistics about the links shared in a set of messages;
+// LinkShare is the share of all links of the analysis shared here
+type LinkStats struct {
+	NumLinks        int            `json:"num_links"`
+	NumLinkMessages int            `json:"num_link_messages"`
+	LinkShare       float64        `json:"link_share"`
+	DomainCounts    map[string]int `json:"domain_counts"`
+	LinkCounts      map[string]int `json:"link_counts"`
+	ClassCounts     map[string]int `json:"class_counts"`
+}
+
+// LoadLinkRules reads link rules from a JSON file holding an array
+// of {"domain": ..., "path_contains": ..., "class": ...} objects
+func LoadLinkRules(filePath string) (rules []LinkRule, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	rulesBytes, err := ioutil.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(rulesBytes, &rules)
+	return
+}
+
+// ExtractLinks takes in message text and returns the text with all http(s)
+// links replaced by spaces along with the normalized and classified links
+func ExtractLinks(text string) (prose string, links []Link) {
+	prose = slackLinkRegexp.ReplaceAllStringFunc(text, func(s string) string {
+		match := slackLinkRegexp.FindStringSubmatch(s)
+		if link, ok := NormalizeLink(match[1]); ok {
+			link.Label = match[2]
+			links = append(links, link)
+		}
+		return " "
+	})
+	prose = bareLinkRegexp.ReplaceAllStringFunc(prose, func(s string) string {
+		if link, ok := NormalizeLink(strings.TrimRight(s, ".,;:!?)")); ok {
+			links = append(links, link)
+		}
+		return " "
+	})
+	return
+}
+
+// StripLinks takes in message text and returns it without any links
+func StripLinks(text string) string {
+	prose, _ := ExtractLinks(text)
+	return prose
+}
+
+// NormalizeLink parses a raw URL and returns it normalized to its
+// domain and path and classified with LinkRules; ok is false if
+// the URL is not a valid http(s) URL
+func NormalizeLink(rawURL string) (link Link, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return
+	}
+	link.Domain = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	link.Path = strings.TrimRight(u.EscapedPath(), "/")
+	link.URL = link.Domain + link.Path
+	link.Class = ClassifyLink(link, LinkRules)
+	ok = true
+	return
+}
+
+// ClassifyLink returns the class of the first rule
+// matching a link, or LinkClassOther
+func ClassifyLink(link Link, rules []LinkRule) string {
+	for _, r := range rules {
+		domain := strings.ToLower(r.Domain)
+		if link.Domain != domain && !strings.HasSuffix(link.Domain, "."+domain) {
+			continue
+		}
+		if r.PathContains != "" && !strings.Contains(link.Path, r.PathContains) {
+			continue
+		}
+		return r.Class
+	}
+	return LinkClassOther
+}
+
+// GetTopDomains takes in link stats and returns the amount
+// most shared domains by frequency descending
+func GetTopDomains(ls LinkStats, amount int) []WordCount {
+	return topCounts(ls.DomainCounts, amount)
+}
+
+// GetTopLinks takes in link stats and returns the amount
+// most shared links by frequency descending
+func GetTopLinks(ls LinkStats, amount int) []WordCount {
+	return topCounts(ls.LinkCounts, amount)
+}
+
+// topCounts returns the amount highest counts of a count map,
+// breaking ties alphabetically
+func topCounts(counts map[string]int, amount int) (top []WordCount) {
+	top = []WordCount{}
+	for k, c := range counts {
+		top = append(top, WordCount{k, c})
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].Count == top[j].Count {
+			return top[i].Word < top[j].Word
+		}
+		return top[i].Count > top[j].Count
+	})
+	if len(top) > amount {
+		top = top[:amount]
+	}
+	return
+}
+
+func newLinkStats() LinkStats {
+	return LinkStats{
+		DomainCounts: make(map[string]int),
+		LinkCounts:   make(map[string]int),
+		ClassCounts:  make(map[string]int),
+	}
+}
+
+// addLinks adds the links of a single message
+func (ls *LinkStats) addLinks(links []Link) {
+	if len(links) == 0 {
+		return
+	}
+	ls.NumLinkMessages += 1
+	for _, l := range links {
+		ls.NumLinks += 1
+		ls.DomainCounts[l.Domain] += 1
+		ls.LinkCounts[l.URL] += 1
+		ls.ClassCounts[l.Class] += 1
+	}
+}
+
+// setLinkShare sets the share of all links that ls holds
+func (ls *LinkStats) setLinkShare(allLinks int) {
+	if allLinks > 0 {
+		ls.LinkShare = float64(ls.NumLinks) / float64(allLinks)
+	}
+}
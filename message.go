@@ -4,20 +4,31 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
-)
+	"unicode"
 
-var (
-	symbols = []rune{'.', ',', '"', '\'', '(', ')', '!', '?', '$', ';', ':'}
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Message struct {
-	User      string `json:"user"`
-	Type      string `json:"type"`
-	SubType   string `json:"subtype"`
-	Text      string `json:"text"`
-	TimeStamp string `json:"ts"`
+	User            string     `json:"user"`
+	Type            string     `json:"type"`
+	SubType         string     `json:"subtype"`
+	Text            string     `json:"text"`
+	TimeStamp       string     `json:"ts"`
+	ThreadTimeStamp string     `json:"thread_ts"`
+	Channel         string     `json:"channel"`
+	Reactions       []Reaction `json:"reactions"`
+}
+
+// Reaction is an emoji reaction to a message
+// along with the users who reacted with it
+type Reaction struct {
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
+	Count int      `json:"count"`
 }
 
 // ReadAllMessages takes in a path to the data folder and returns
@@ -65,6 +76,11 @@ func ReadChannelMessages(channelPath string) (messages []Message, err error) {
 		if err != nil {
 			continue
 		}
+		for i := range dayMessages {
+			if dayMessages[i].Channel == "" {
+				dayMessages[i].Channel = filepath.Base(channelPath)
+			}
+		}
 		messages = append(messages, dayMessages...)
 	}
 	return
@@ -96,51 +112,68 @@ func FilterMessagesByUser(messages []Message, userId string) (filteredMessages [
 	return
 }
 
-// MessageToWords takes in a message and returns a slice of words (strings);
-// optionally trims symbols from individual words and converts to lowercase
+// WordOptions configures how MessageToWordsWithOptions normalizes words
+type WordOptions struct {
+	// TrimSymbols trims punctuation (Unicode category P) and a few symbols
+	// (see isSymbol) from the start and end of each word and drops words
+	// made only of them
+	TrimSymbols bool
+	// Lower converts words to lowercase
+	Lower bool
+	// Fold applies NFKC normalization and Unicode case folding, so that
+	// e.g. "Straße", "STRASSE" and "ｓｔｒａｓｓｅ" all become "strasse"
+	Fold bool
+	// KeepCode keeps the words of code blocks, inline code and links,
+	// which are otherwise left out (see ExtractCode and ExtractLinks)
+	KeepCode bool
+}
+
+// MessageToWords takes in a message and returns a slice of words (strings)
+// outside of code and links; optionally trims symbols from individual words and
+// converts to lowercase
 func MessageToWords(m Message, trimSymbols, lower bool) (words []string) {
-	words = strings.Fields(m.Text)
-	if !trimSymbols {
-		if lower {
-			for i, w := range words {
-				words[i] = strings.ToLower(w)
-			}
-		}
-		return
+	return MessageToWordsWithOptions(m, WordOptions{TrimSymbols: trimSymbols, Lower: lower})
+}
+
+// MessageToWordsWithOptions takes in a message and returns a slice of
+// words normalized with NormalizeWord; invalid UTF-8 in the message is
+// replaced with U+FFFD so every word is valid UTF-8
+func MessageToWordsWithOptions(m Message, opt WordOptions) (words []string) {
+	words = []string{}
+	text := m.Text
+	if !opt.KeepCode {
+		text = proseText(text)
 	}
-	// trim symbols
-	for i, w := range words {
-		start := 0
-		end := len(w)
-		for j, b := range w {
-			if !isSymbol(b) {
-				start = j
-				break
-			}
-		}
-		for j := len(w) - 1; j >= 0; j-- {
-			if !isSymbol(rune(w[j])) {
-				end = j + 1
-				break
-			}
-		}
-		trimmed := string(w[start:end])
-		if lower {
-			words[i] = strings.ToLower(trimmed)
-		} else {
-			words[i] = trimmed
+	for _, w := range strings.Fields(strings.ToValidUTF8(text, string(unicode.ReplacementChar))) {
+		w = NormalizeWord(w, opt)
+		if w != "" {
+			words = append(words, w)
 		}
 	}
 	return
 }
 
+// NormalizeWord takes in a single word and normalizes it as described
+// by opt; trimming works on whole runes, so it never splits a character
+func NormalizeWord(w string, opt WordOptions) string {
+	w = strings.ToValidUTF8(w, string(unicode.ReplacementChar))
+	if opt.Fold {
+		w = cases.Fold().String(norm.NFKC.String(w))
+	} else if opt.Lower {
+		w = strings.ToLower(w)
+	}
+	if opt.TrimSymbols {
+		w = strings.TrimFunc(w, isSymbol)
+	}
+	return w
+}
+
+// ParseWords takes in a message and returns its words outside of code
+// and links and its normalized emojis (:shortcode: and Unicode);
+// optionally converts words to lowercase
 func ParseWords(m Message, lower bool) (words []string, emojis []string) {
 	// extract all emojis and replace them with spaces
-	r, _ := regexp.Compile(":[-_a-zA-Z0-9]+:")
-	text := r.ReplaceAllStringFunc(m.Text, func(s string) string {
-		emojis = append(emojis, s)
-		return " "
-	})
+	text, emojis := extractEmojis(proseText(m.Text))
 	pieces := strings.Fields(text)
 	for _, p := range pieces {
 		if lower {
@@ -151,23 +184,46 @@ func ParseWords(m Message, lower bool) (words []string, emojis []string) {
 	return
 }
 
+// TokenizeMessage takes in a message and returns the words and emojis all
+// stats are computed from, so that every command counts them the same way:
+// emojis (:shortcode: and Unicode) are taken from the text outside of code
+// and links, and the remaining words are trimmed and lowercased
+func TokenizeMessage(m Message) (words []string, emojis []string) {
+	return tokenizeProse(proseText(m.Text))
+}
+
+// tokenizeProse is TokenizeMessage for text already
+// stripped of code and links
+func tokenizeProse(prose string) (words []string, emojis []string) {
+	text, emojis := extractEmojis(prose)
+	words = MessageToWordsWithOptions(Message{Text: text}, WordOptions{TrimSymbols: true, Lower: true, KeepCode: true})
+	return
+}
+
+// GetEmojis takes in a slice of words and returns
+// the normalized emojis among them
 func GetEmojis(words []string) (emojis []string) {
-	r, _ := regexp.Compile("^:[-_a-zA-Z0-9]+:$")
 	for _, w := range words {
-		if r.MatchString(w) {
-			emojis = append(emojis, w)
+		if emojiWordRegexp.MatchString(w) {
+			emojis = append(emojis, NormalizeEmoji(w))
 		}
 	}
 	return
 }
 
-// isSymbol decides whether a
-// rune is in the symbol list
+// proseText returns the text of a message
+// without code and without links
+func proseText(text string) string {
+	return StripLinks(StripCode(text))
+}
+
+// trimmedSymbols are the symbols (Unicode category S) trimmed from words
+// along with punctuation; other symbols such as "+", currency signs and
+// emojis are part of words like "+1", "c++" or "$5"
+var trimmedSymbols = "`^|~<>="
+
+// isSymbol decides whether a rune is punctuation (Unicode
+// category P) or one of trimmedSymbols
 func isSymbol(r rune) bool {
-	for _, s := range symbols {
-		if s == r {
-			return true
-		}
-	}
-	return false
+	return unicode.IsPunct(r) || strings.ContainsRune(trimmedSymbols, r)
 }
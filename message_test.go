@@ -0,0 +1,79 @@
+package slackanalytics
+
+import (
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+)
+
+func wordOptions(trim, lower, fold, keepCode bool) WordOptions {
+	return WordOptions{TrimSymbols: trim, Lower: lower, Fold: fold, KeepCode: keepCode}
+}
+
+func TestNormalizeWordValidUTF8(t *testing.T) {
+	f := func(b []byte, trim, lower, fold bool) bool {
+		return utf8.ValidString(NormalizeWord(string(b), wordOptions(trim, lower, fold, false)))
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMessageToWordsValidUTF8(t *testing.T) {
+	f := func(b []byte, trim, lower, fold, keepCode bool) bool {
+		for _, w := range MessageToWordsWithOptions(Message{Text: string(b)}, wordOptions(trim, lower, fold, keepCode)) {
+			if !utf8.ValidString(w) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzNormalizeWord(f *testing.F) {
+	for _, seed := range []string{"Straße", "\xff\xfe", "c++", "+1", "(hello),", "👍", "é\xc3"} {
+		f.Add(seed, true, true, true)
+	}
+	f.Fuzz(func(t *testing.T, w string, trim, lower, fold bool) {
+		if n := NormalizeWord(w, wordOptions(trim, lower, fold, false)); !utf8.ValidString(n) {
+			t.Errorf("NormalizeWord(%q) = %q is not valid UTF-8", w, n)
+		}
+	})
+}
+
+func FuzzMessageToWords(f *testing.F) {
+	for _, seed := range []string{"hi `code\xff` there", "see https://example.com/\xc3", "```\xfe```", "*bold* _it_ ~st~"} {
+		f.Add(seed, true, false)
+	}
+	f.Fuzz(func(t *testing.T, text string, trim, keepCode bool) {
+		for _, w := range MessageToWordsWithOptions(Message{Text: text}, wordOptions(trim, true, true, keepCode)) {
+			if !utf8.ValidString(w) {
+				t.Errorf("MessageToWordsWithOptions(%q) returned %q, which is not valid UTF-8", text, w)
+			}
+		}
+	})
+}
+
+func TestNormalizeWordTrimsPunctuation(t *testing.T) {
+	opt := WordOptions{TrimSymbols: true}
+	tests := []struct {
+		in, want string
+	}{
+		{"(hello),", "hello"},
+		{"«quoted»", "quoted"},
+		{"`code`", "code"},
+		{"+1", "+1"},
+		{"c++", "c++"},
+		{"$5", "$5"},
+		{"👍", "👍"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeWord(tt.in, opt); got != tt.want {
+			t.Errorf("NormalizeWord(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
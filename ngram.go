@@ -0,0 +1,167 @@
+package slackanalytics
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// PhraseCount holds a phrase of two or more words along with its
+// frequency and collocation scores (pointwise mutual information in
+// bits and Dunning's log-likelihood ratio)
+type PhraseCount struct {
+	Phrase        string  `json:"phrase"`
+	Count         int     `json:"count"`
+	PMI           float64 `json:"pmi"`
+	LogLikelihood float64 `json:"log_likelihood"`
+}
+
+type sortPhrasesByCount []PhraseCount
+
+func (s sortPhrasesByCount) Len() int {
+	return len(s)
+}
+
+func (s sortPhrasesByCount) Less(i, j int) bool {
+	return s[i].Count > s[j].Count
+}
+
+func (s sortPhrasesByCount) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// GetNgrams takes in the words of a single message and returns
+// its n-grams, each made of n consecutive words joined by a space
+func GetNgrams(words []string, n int) (ngrams []string) {
+	nonEmpty := []string{}
+	for _, w := range words {
+		if w != "" {
+			nonEmpty = append(nonEmpty, w)
+		}
+	}
+	for i := 0; i+n <= len(nonEmpty); i++ {
+		ngrams = append(ngrams, strings.Join(nonEmpty[i:i+n], " "))
+	}
+	return
+}
+
+// GetSortedBigrams takes in word stats and returns scored
+// bigram counts sorted by frequency descending
+func GetSortedBigrams(ws *WordStats) []PhraseCount {
+	return getSortedPhrases(ws.BigramCountMap, nil, ws.WordCountMap, ws.TotalWords)
+}
+
+// GetSortedTrigrams takes in word stats and returns scored
+// trigram counts sorted by frequency descending
+func GetSortedTrigrams(ws *WordStats) []PhraseCount {
+	return getSortedPhrases(ws.TrigramCountMap, ws.BigramCountMap, ws.WordCountMap, ws.TotalWords)
+}
+
+// GetSortedMsgBigrams takes in message stats and returns
+// scored bigram counts sorted by frequency descending
+func GetSortedMsgBigrams(ms *MessageStats) []PhraseCount {
+	return getSortedPhrases(ms.BigramCountMap, nil, ms.WordCountMap, ms.NumWords)
+}
+
+// GetSortedMsgTrigrams takes in message stats and returns
+// scored trigram counts sorted by frequency descending
+func GetSortedMsgTrigrams(ms *MessageStats) []PhraseCount {
+	return getSortedPhrases(ms.TrigramCountMap, ms.BigramCountMap, ms.WordCountMap, ms.NumWords)
+}
+
+// GetTopPhrases takes in a slice of phrases (sorted by frequency or score)
+// and returns the top amount of them, skipping phrases that start or end
+// with a common word if includeCommon is false
+func GetTopPhrases(phraseCounts []PhraseCount, amount int, includeCommon bool) (topPhraseCounts []PhraseCount) {
+	topPhraseCounts = []PhraseCount{}
+	for _, pc := range phraseCounts {
+		if len(topPhraseCounts) >= amount {
+			break
+		}
+		if !includeCommon {
+			words := strings.Fields(strings.ToLower(pc.Phrase))
+			if len(words) == 0 || IsStopword(words[0]) || IsStopword(words[len(words)-1]) {
+				continue
+			}
+		}
+		topPhraseCounts = append(topPhraseCounts, pc)
+	}
+	return
+}
+
+// SortPhrasesByPMI returns the phrases seen at least minCount
+// times sorted by pointwise mutual information descending
+func SortPhrasesByPMI(phraseCounts []PhraseCount, minCount int) (sorted []PhraseCount) {
+	for _, pc := range phraseCounts {
+		if pc.Count >= minCount {
+			sorted = append(sorted, pc)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PMI > sorted[j].PMI
+	})
+	return
+}
+
+// SortPhrasesByLogLikelihood returns the phrases
+// sorted by log-likelihood ratio descending
+func SortPhrasesByLogLikelihood(phraseCounts []PhraseCount) (sorted []PhraseCount) {
+	sorted = append([]PhraseCount{}, phraseCounts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LogLikelihood > sorted[j].LogLikelihood
+	})
+	return
+}
+
+// getSortedPhrases scores each phrase against the word counts; trigrams
+// are scored for log-likelihood as their leading bigram followed by a word
+func getSortedPhrases(phraseCountMap, bigramCountMap, wordCountMap map[string]int, numWords int) (phraseCounts []PhraseCount) {
+	phraseCounts = make([]PhraseCount, 0, len(phraseCountMap))
+	n := float64(numWords)
+	for phrase, count := range phraseCountMap {
+		pc := PhraseCount{Phrase: phrase, Count: count}
+		words := strings.Split(phrase, " ")
+		if n > 0 {
+			c := float64(count)
+			pmi := math.Log2(c)
+			for _, w := range words {
+				pmi += math.Log2(n) - math.Log2(math.Max(float64(wordCountMap[w]), 1))
+			}
+			pc.PMI = pmi - math.Log2(n)
+			head := float64(wordCountMap[words[0]])
+			if len(words) == 3 && bigramCountMap != nil {
+				head = float64(bigramCountMap[words[0]+" "+words[1]])
+			}
+			tail := float64(wordCountMap[words[len(words)-1]])
+			pc.LogLikelihood = logLikelihoodRatio(c, head, tail, n)
+		}
+		phraseCounts = append(phraseCounts, pc)
+	}
+	sort.Sort(sortPhrasesByCount(phraseCounts))
+	return
+}
+
+// logLikelihoodRatio computes Dunning's G^2 for a 2x2 contingency table
+// built from the joint count, the two marginal counts and the total
+func logLikelihoodRatio(joint, head, tail, total float64) float64 {
+	k := [4]float64{
+		joint,
+		math.Max(head-joint, 0),
+		math.Max(tail-joint, 0),
+		math.Max(total-head-tail+joint, 0),
+	}
+	rows := [2]float64{k[0] + k[1], k[2] + k[3]}
+	cols := [2]float64{k[0] + k[2], k[1] + k[3]}
+	sum := rows[0] + rows[1]
+	g2 := 0.0
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			o := k[i*2+j]
+			e := rows[i] * cols[j] / sum
+			if o > 0 && e > 0 {
+				g2 += o * math.Log(o/e)
+			}
+		}
+	}
+	return 2 * g2
+}
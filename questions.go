@@ -0,0 +1,201 @@
+package slackanalytics
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	mentionRegexp        = regexp.MustCompile(`<@([UW][A-Z0-9]+)(?:\|[^>]*)?>`)
+	leadingMentionRegexp = regexp.MustCompile(`^\s*(<@[UW][A-Z0-9]+(?:\|[^>]*)?>[\s,:]*)+`)
+
+	// InterrogativeWords are the words that make a message
+	// a question when it starts with one of them
+	InterrogativeWords = []string{"who", "what", "when", "where", "why", "how", "which", "whose", "whom", "can", "could", "would", "should", "does", "do", "did", "is", "are", "was", "were", "will", "has", "have", "anyone", "anybody"}
+)
+
+// QuestionOptions configures FindQuestions; a question counts as answered
+// if someone else replies in its thread or in the channel within Window,
+// and WorkspaceURL (e.g. https://acme.slack.com) prefixes permalinks
+type QuestionOptions struct {
+	Window       time.Duration
+	WorkspaceURL string
+}
+
+// Question is a message asking a question along with
+// whether and by whom it was answered
+type Question struct {
+	Channel         string   `json:"channel"`
+	ChannelId       string   `json:"channel_id"`
+	User            string   `json:"user"`
+	TimeStamp       string   `json:"ts"`
+	Text            string   `json:"text"`
+	Permalink       string   `json:"permalink"`
+	Mentions        []string `json:"mentions"`
+	Answered        bool     `json:"answered"`
+	AnsweredBy      string   `json:"answered_by"`
+	AnswerTimeStamp string   `json:"answer_ts"`
+}
+
+// QuestionReport holds the number of questions found and
+// the unanswered questions per channel name, oldest first
+type QuestionReport struct {
+	NumQuestions int                   `json:"num_questions"`
+	NumAnswered  int                   `json:"num_answered"`
+	Unanswered   map[string][]Question `json:"unanswered"`
+}
+
+// DefaultQuestionOptions counts replies in the
+// channel within two hours as answers
+func DefaultQuestionOptions() QuestionOptions {
+	return QuestionOptions{
+		Window: 2 * time.Hour,
+	}
+}
+
+// IsQuestion decides whether a message asks a question: its text (outside
+// of code and links) has a question mark or starts with an interrogative
+// word, or it starts with a direct @mention and uses an interrogative word
+func IsQuestion(m Message) bool {
+	text := proseText(m.Text)
+	if strings.Contains(text, "?") {
+		return true
+	}
+	mentioned := leadingMentionRegexp.MatchString(text)
+	words := MessageToWords(Message{Text: leadingMentionRegexp.ReplaceAllString(text, "")}, true, true)
+	if len(words) < 2 {
+		return false
+	}
+	if inList(words[0], InterrogativeWords) {
+		return true
+	}
+	if mentioned {
+		for _, w := range words {
+			if inList(w, InterrogativeWords) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetMentions returns the ids of the users mentioned in a message
+func GetMentions(m Message) (userIds []string) {
+	for _, match := range mentionRegexp.FindAllStringSubmatch(m.Text, -1) {
+		if !inList(match[1], userIds) {
+			userIds = append(userIds, match[1])
+		}
+	}
+	return
+}
+
+// FindQuestions finds the questions asked in each channel and decides
+// whether they were answered: by a later reply from someone else in the
+// question's thread, or, for a top-level question, by a later top-level
+// channel message from someone else within opt.Window; if a question
+// mentions people, only a reply from one of them counts as an answer
+func FindQuestions(channels []*Channel, opt QuestionOptions) (report QuestionReport) {
+	report = QuestionReport{
+		Unanswered: make(map[string][]Question),
+	}
+	for _, c := range channels {
+		messages := append([]Message{}, c.Messages...)
+		sort.SliceStable(messages, func(i, j int) bool {
+			return timeStampSeconds(messages[i].TimeStamp) < timeStampSeconds(messages[j].TimeStamp)
+		})
+		// thread replies in time order by the ts of their thread
+		replies := make(map[string][]Message)
+		for _, m := range messages {
+			if !isTopLevel(m) {
+				replies[m.ThreadTimeStamp] = append(replies[m.ThreadTimeStamp], m)
+			}
+		}
+		for i, m := range messages {
+			if m.Text == "" || (m.SubType != "" && m.SubType != "thread_broadcast") || !IsQuestion(m) {
+				continue
+			}
+			q := Question{
+				Channel:   c.Name,
+				ChannelId: c.Id,
+				User:      m.User,
+				TimeStamp: m.TimeStamp,
+				Text:      m.Text,
+				Permalink: Permalink(opt.WorkspaceURL, c.Id, m),
+				Mentions:  GetMentions(m),
+			}
+			asked := timeStampSeconds(m.TimeStamp)
+			thread := m.ThreadTimeStamp
+			if thread == "" {
+				thread = m.TimeStamp
+			}
+			var answer *Message
+			for j, r := range replies[thread] {
+				if timeStampSeconds(r.TimeStamp) > asked && isAnswer(q, r) {
+					answer = &replies[thread][j]
+					break
+				}
+			}
+			if isTopLevel(m) {
+				for j, r := range messages[i+1:] {
+					if timeStampSeconds(r.TimeStamp)-asked > opt.Window.Seconds() {
+						break
+					}
+					if answer != nil && timeStampSeconds(r.TimeStamp) >= timeStampSeconds(answer.TimeStamp) {
+						break
+					}
+					if isTopLevel(r) && isAnswer(q, r) {
+						answer = &messages[i+1+j]
+						break
+					}
+				}
+			}
+			if answer != nil {
+				q.Answered = true
+				q.AnsweredBy = answer.User
+				q.AnswerTimeStamp = answer.TimeStamp
+			}
+			report.NumQuestions += 1
+			if q.Answered {
+				report.NumAnswered += 1
+				continue
+			}
+			report.Unanswered[c.Name] = append(report.Unanswered[c.Name], q)
+		}
+	}
+	return
+}
+
+// isTopLevel decides whether a message is posted in the channel rather
+// than in a thread; a thread parent's thread_ts equals its own ts
+func isTopLevel(m Message) bool {
+	return m.ThreadTimeStamp == "" || m.ThreadTimeStamp == m.TimeStamp
+}
+
+// isAnswer decides whether a message can answer a question: it is
+// posted by someone else and by one of the people mentioned, if any
+func isAnswer(q Question, r Message) bool {
+	if r.User == q.User || r.User == "" || r.SubType == "channel_join" {
+		return false
+	}
+	return len(q.Mentions) == 0 || inList(r.User, q.Mentions)
+}
+
+// Permalink returns the link to a message in a channel; thread replies
+// link to their place in the thread and, without a workspace URL, the
+// link is relative to the workspace
+func Permalink(workspaceURL, channelId string, m Message) string {
+	link := strings.TrimRight(workspaceURL, "/") + "/archives/" + channelId + "/p" + strings.Replace(m.TimeStamp, ".", "", 1)
+	if m.ThreadTimeStamp != "" && m.ThreadTimeStamp != m.TimeStamp {
+		link += "?thread_ts=" + m.ThreadTimeStamp + "&cid=" + channelId
+	}
+	return link
+}
+
+// timeStampSeconds parses a Slack timestamp into seconds
+func timeStampSeconds(ts string) float64 {
+	t, _ := strconv.ParseFloat(ts, 64)
+	return t
+}
@@ -0,0 +1,21 @@
+package slackanalytics
+
+import "testing"
+
+func TestFindQuestionsThreadReplies(t *testing.T) {
+	c := &Channel{Id: "C1", Name: "eng", Messages: []Message{
+		{User: "U1", Text: "deploying the cache today", TimeStamp: "1000.000100", ThreadTimeStamp: "1000.000100"},
+		{User: "U2", Text: "who owns the cache now?", TimeStamp: "1010.000100", ThreadTimeStamp: "1000.000100"},
+		{User: "U3", Text: "the platform team does", TimeStamp: "90000.000100", ThreadTimeStamp: "1000.000100"},
+		{User: "U4", Text: "is anyone around?", TimeStamp: "2000.000100"},
+		{User: "U5", Text: "lunch soon", TimeStamp: "20000.000100"},
+	}}
+	report := FindQuestions([]*Channel{c}, DefaultQuestionOptions())
+	if report.NumQuestions != 2 || report.NumAnswered != 1 {
+		t.Fatalf("got %d questions, %d answered, want 2 and 1", report.NumQuestions, report.NumAnswered)
+	}
+	unanswered := report.Unanswered["eng"]
+	if len(unanswered) != 1 || unanswered[0].User != "U4" {
+		t.Errorf("unanswered = %+v, want only the question of U4", unanswered)
+	}
+}
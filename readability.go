@@ -0,0 +1,163 @@
+package slackanalytics
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// mtldThreshold is the type-token ratio at
+	// which MTLD closes a lexical factor
+	mtldThreshold = 0.72
+)
+
+var (
+	sentenceEndRegexp = regexp.MustCompile(`[.!?]+(\s|$)|\n+`)
+)
+
+// Readability holds readability and vocabulary richness metrics
+// for a set of messages; Flesch, Flesch-Kincaid and Gunning fog use
+// the standard formulas over words, sentences and syllables
+type Readability struct {
+	NumSentences       int     `json:"num_sentences"`
+	NumSyllables       int     `json:"num_syllables"`
+	NumComplexWords    int     `json:"num_complex_words"`
+	SyllablesPerWord   float64 `json:"syllables_per_word"`
+	WordsPerSentence   float64 `json:"words_per_sentence"`
+	FleschReadingEase  float64 `json:"flesch_reading_ease"`
+	FleschKincaidGrade float64 `json:"flesch_kincaid_grade"`
+	GunningFog         float64 `json:"gunning_fog"`
+	TypeTokenRatio     float64 `json:"type_token_ratio"`
+	MTLD               float64 `json:"mtld"`
+	tokens             []string
+}
+
+// SplitSentences takes in message text and returns its sentences;
+// sentences end with ., ! or ? followed by a space, or with a line break
+func SplitSentences(text string) (sentences []string) {
+	for _, s := range sentenceEndRegexp.Split(text, -1) {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return
+}
+
+// CountSyllables estimates the number of syllables of an english
+// word by counting vowel groups, ignoring a silent trailing e
+func CountSyllables(word string) (syllables int) {
+	word = strings.ToLower(word)
+	letters := []rune{}
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			letters = append(letters, r)
+		}
+	}
+	if len(letters) == 0 {
+		return 0
+	}
+	prevVowel := false
+	for _, r := range letters {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			syllables += 1
+		}
+		prevVowel = vowel
+	}
+	n := len(letters)
+	if n > 2 && letters[n-1] == 'e' && letters[n-2] != 'l' && !strings.ContainsRune("aeiouy", letters[n-2]) {
+		syllables -= 1
+	}
+	if syllables < 1 {
+		syllables = 1
+	}
+	return
+}
+
+// GetTypeTokenRatio returns the number of distinct
+// tokens divided by the total number of tokens
+func GetTypeTokenRatio(tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	types := make(map[string]bool)
+	for _, t := range tokens {
+		types[t] = true
+	}
+	return float64(len(types)) / float64(len(tokens))
+}
+
+// GetMTLD returns the measure of textual lexical diversity of a token
+// stream, averaged over a forward and a backward pass
+func GetMTLD(tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	reversed := make([]string, len(tokens))
+	for i, t := range tokens {
+		reversed[len(tokens)-1-i] = t
+	}
+	return (mtldPass(tokens) + mtldPass(reversed)) / 2
+}
+
+// addSentences adds the sentences of a message
+func (r *Readability) addSentences(text string) {
+	r.NumSentences += len(SplitSentences(text))
+}
+
+// addWord adds a single word of a message
+func (r *Readability) addWord(w string) {
+	syllables := CountSyllables(w)
+	r.NumSyllables += syllables
+	if syllables >= 3 {
+		r.NumComplexWords += 1
+	}
+	r.tokens = append(r.tokens, strings.ToLower(w))
+}
+
+// setScores computes the readability scores from the
+// collected counts and releases the token stream
+func (r *Readability) setScores() {
+	numWords := float64(len(r.tokens))
+	if numWords == 0 || r.NumSentences == 0 {
+		r.tokens = nil
+		return
+	}
+	r.SyllablesPerWord = float64(r.NumSyllables) / numWords
+	r.WordsPerSentence = numWords / float64(r.NumSentences)
+	r.FleschReadingEase = 206.835 - 1.015*r.WordsPerSentence - 84.6*r.SyllablesPerWord
+	r.FleschKincaidGrade = 0.39*r.WordsPerSentence + 11.8*r.SyllablesPerWord - 15.59
+	r.GunningFog = 0.4 * (r.WordsPerSentence + 100*float64(r.NumComplexWords)/numWords)
+	r.TypeTokenRatio = GetTypeTokenRatio(r.tokens)
+	r.MTLD = GetMTLD(r.tokens)
+	r.tokens = nil
+}
+
+// mtldPass counts how many factors of a token stream keep a
+// type-token ratio above the threshold, including a partial factor
+func mtldPass(tokens []string) float64 {
+	factors := 0.0
+	types := make(map[string]bool)
+	count := 0
+	ttr := 1.0
+	for _, t := range tokens {
+		types[t] = true
+		count += 1
+		ttr = float64(len(types)) / float64(count)
+		if ttr <= mtldThreshold {
+			factors += 1
+			types = make(map[string]bool)
+			count = 0
+			ttr = 1.0
+		}
+	}
+	if count > 0 {
+		factors += (1 - ttr) / (1 - mtldThreshold)
+	}
+	if factors == 0 {
+		return float64(len(tokens))
+	}
+	return float64(len(tokens)) / factors
+}
@@ -0,0 +1,181 @@
+package slackanalytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Report gathers the stats shown by the HTML report and the Markdown
+// digest: overview numbers, users and channels by activity, top terms
+// and daily and monthly activity in chronological order
+type Report struct {
+	Title       string
+	Generated   time.Time
+	Overview    *MessageStats
+	NumUsers    int
+	NumChannels int
+	FirstDay    string
+	LastDay     string
+	Users       []ReportRow
+	Channels    []ReportRow
+	TopWords    []WordCount
+	TopEmojis   []WordCount
+	Categories  []WordCount
+	Daily       []ReportPeriod
+	Monthly     []ReportPeriod
+}
+
+// ReportRow is the stats of a user or channel; Key
+// is the user id or channel name and Name is what to show
+type ReportRow struct {
+	Key   string
+	Name  string
+	Stats *MessageStats
+}
+
+// ReportPeriod is the stats of a day or month
+type ReportPeriod struct {
+	Period string
+	Stats  *MessageStats
+}
+
+// BuildReport builds a report from message stats, naming users after
+// their display names and keeping the amount top words and emojis
+func BuildReport(s SlackMessageStats, users []*User, amount int) (r Report) {
+	names := make(map[string]string)
+	for _, u := range users {
+		names[u.Id] = GetUserName(u)
+	}
+	r = Report{
+		Title:       "Slack Analytics",
+		Generated:   time.Unix(int64(s.Time), 0),
+		Overview:    s.AllStats,
+		NumUsers:    len(s.UserStats),
+		NumChannels: len(s.ChannelStats),
+		Users:       reportRows(s.UserStats, names),
+		Channels:    reportRows(s.ChannelStats, nil),
+		Daily:       reportPeriods(s.DailyStats),
+		Monthly:     reportPeriods(s.MonthlyStats),
+	}
+	if r.Overview == nil {
+		r.Overview = newMessageStats()
+	}
+	if len(r.Daily) > 0 {
+		r.FirstDay = r.Daily[0].Period
+		r.LastDay = r.Daily[len(r.Daily)-1].Period
+	}
+	r.TopWords = GetTopReportWords(r.Overview, amount)
+	r.TopEmojis = topCounts(r.Overview.EmojiCountMap, amount)
+	r.Categories = topCounts(r.Overview.CategoryCounts, len(r.Overview.CategoryCounts))
+	return
+}
+
+// BuildSlackStatsReport builds a report from word stats, naming users and
+// channels; word stats have no emojis or periods, so a report built this
+// way has no top emojis and no daily or monthly activity
+func BuildSlackStatsReport(ss SlackStats, users []*User, channels []*Channel, amount int) (r Report) {
+	names := make(map[string]string)
+	for _, u := range users {
+		names[u.Id] = GetUserName(u)
+	}
+	for _, c := range channels {
+		names[c.Id] = c.Name
+	}
+	userStats := make(map[string]*MessageStats)
+	for k, ws := range ss.UserStats {
+		if ws.TotalMessages > 0 {
+			userStats[k] = wordStatsToMessageStats(ws)
+		}
+	}
+	channelStats := make(map[string]*MessageStats)
+	for k, ws := range ss.ChannelStats {
+		channelStats[k] = wordStatsToMessageStats(ws)
+	}
+	r = Report{
+		Title:       "Slack Analytics",
+		Generated:   time.Now(),
+		Overview:    newMessageStats(),
+		NumUsers:    len(userStats),
+		NumChannels: len(channelStats),
+		Users:       reportRows(userStats, names),
+		Channels:    reportRows(channelStats, names),
+	}
+	if ss.AllStats != nil {
+		r.Overview = wordStatsToMessageStats(ss.AllStats)
+	}
+	r.TopWords = GetTopReportWords(r.Overview, amount)
+	r.TopEmojis = []WordCount{}
+	r.Categories = topCounts(r.Overview.CategoryCounts, len(r.Overview.CategoryCounts))
+	return
+}
+
+// GetTopReportWords returns the amount most frequent words of message
+// stats, merging case variants and skipping stopwords and non-words
+func GetTopReportWords(ms *MessageStats, amount int) []WordCount {
+	counts := make(map[string]int)
+	for w, c := range lowerWordCounts(ms.WordCountMap) {
+		w = strings.TrimFunc(w, isSymbol)
+		if w == "" || ms.isStopword(w) || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		counts[w] += c
+	}
+	return topCounts(counts, amount)
+}
+
+// reportRows returns the rows of a stats group by number of
+// messages descending, named with names if given
+func reportRows(stats map[string]*MessageStats, names map[string]string) (rows []ReportRow) {
+	for k, ms := range stats {
+		name := k
+		if n, ok := names[k]; ok && n != "" {
+			name = n
+		}
+		rows = append(rows, ReportRow{Key: k, Name: name, Stats: ms})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Stats.NumMessages == rows[j].Stats.NumMessages {
+			return rows[i].Name < rows[j].Name
+		}
+		return rows[i].Stats.NumMessages > rows[j].Stats.NumMessages
+	})
+	return
+}
+
+// reportPeriods returns the periods of a stats group in order
+func reportPeriods(stats map[string]*MessageStats) (periods []ReportPeriod) {
+	for _, k := range sortedStatsKeys(stats) {
+		periods = append(periods, ReportPeriod{Period: k, Stats: stats[k]})
+	}
+	return
+}
+
+// wordStatsToMessageStats copies word stats into message
+// stats so that both can be shown the same way
+func wordStatsToMessageStats(ws *WordStats) *MessageStats {
+	return &MessageStats{
+		NumMessages:       ws.TotalMessages,
+		NumScoredMessages: ws.TotalScoredMsgs,
+		NumWords:          ws.TotalWords,
+		TotalTextLength:   ws.TotalTextLength,
+		AvgWordLength:     ws.AvgWordLength,
+		AvgWordsPerMsg:    ws.AvgWordsPerMsg,
+		AvgCloutPerMsg:    ws.AvgCloutPerMsg,
+		AvgTonePerMsg:     ws.AvgTonePerMsg,
+		AvgAnalyticPerMsg: ws.AvgAnalyticPerMsg,
+		Scores:            ws.Scores,
+		Readability:       ws.Readability,
+		Code:              ws.Code,
+		Links:             ws.Links,
+		WordCountMap:      ws.WordCountMap,
+		stopwordCounts:    ws.stopwordCounts,
+		BigramCountMap:    ws.BigramCountMap,
+		TrigramCountMap:   ws.TrigramCountMap,
+		EmojiCountMap:     make(map[string]int),
+		CategoryCounts:    ws.CategoryCounts,
+		EmotionCounts:     ws.EmotionCounts,
+		EmotionShares:     ws.EmotionShares,
+	}
+}
@@ -0,0 +1,29 @@
+package slackanalytics
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+// StatsSchemaVersion is the version of the JSON shape of
+// SlackMessageStats; it is bumped whenever a field is renamed,
+// removed or changes type, so that consumers can tell
+const StatsSchemaVersion = 1
+
+// StatsJSONSchema is the JSON Schema document describing the
+// stats JSON of StatsSchemaVersion (schema/stats.schema.json)
+//
+//go:embed schema/stats.schema.json
+var StatsJSONSchema []byte
+
+// CheckSchemaVersion returns an error if stats were written with another
+// version of the JSON schema, telling how to get stats of this version
+func CheckSchemaVersion(s SlackMessageStats) error {
+	switch {
+	case s.SchemaVersion == 0:
+		return fmt.Errorf("stats were written before schema versions (want version %d); re-export them with this version of analyze export, or compare the data folders instead", StatsSchemaVersion)
+	case s.SchemaVersion != StatsSchemaVersion:
+		return fmt.Errorf("stats have schema version %d, want %d; re-export them with this version of analyze export", s.SchemaVersion, StatsSchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package slackanalytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// goldenMessages mix words with punctuation, emojis, code and links
+// and span two days and two months
+var goldenMessages = []Message{
+	{User: "U1", Channel: "general", Text: "Great work, team! :tada: see https://example.com/docs", TimeStamp: "1598918400.000100"},
+	{User: "U2", Channel: "general", Text: "Thanks 👍 the `make build` step is fixed.", TimeStamp: "1598918500.000100"},
+	{User: "U1", Channel: "random", Text: "We are happy: no more *flaky* tests :smile:", TimeStamp: "1601510400.000100"},
+	{User: "U2", Channel: "random", Text: "I think the deploy failed again, can you check?", TimeStamp: "1601596800.000100"},
+}
+
+// goldenStats returns the stats of goldenMessages with alerts and a
+// fixed analysis time; days and months are keyed in UTC so that the
+// golden file does not depend on the local time zone
+func goldenStats(t *testing.T) SlackMessageStats {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+	s := AnalyzeMessages(goldenMessages)
+	s.Time = 1601600000
+	report, err := RunAlertRules(goldenMessages, []AlertRule{{Name: "praise", Keywords: []string{"great work", "thanks"}, Threshold: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Alerts = &report
+	return s
+}
+
+func TestExportGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSlackMessageStats(&buf, goldenStats(t), ExportJSON); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "stats.golden.json")
+	if *update {
+		if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("export differs from %s; if the change is intended, bump StatsSchemaVersion if needed and run go test -update", golden)
+	}
+}
+
+func TestExportMatchesSchema(t *testing.T) {
+	s := goldenStats(t)
+	s.Topics = BuildTopicModel(goldenMessages, DefaultTopicOptions())
+	statsBytes, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema, doc interface{}
+	if err := json.Unmarshal(StatsJSONSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(statsBytes))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	v := schemaValidator{root: schema.(map[string]interface{})}
+	v.validate("", v.root, doc)
+	for _, e := range v.errs {
+		t.Error(e)
+	}
+}
+
+// schemaValidator checks a document against the subset of JSON Schema
+// used by StatsJSONSchema: $ref to $defs, type, const, minimum, maximum,
+// properties, required, additionalProperties and items
+type schemaValidator struct {
+	root map[string]interface{}
+	errs []string
+}
+
+func (v *schemaValidator) validate(path string, schema, doc interface{}) {
+	s := schema.(map[string]interface{})
+	if ref, ok := s["$ref"].(string); ok {
+		def := v.root["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")]
+		if def == nil {
+			v.errorf(path, "unknown $ref "+ref)
+			return
+		}
+		v.validate(path, def, doc)
+	}
+	if t, ok := s["type"]; ok && !v.hasType(t, doc) {
+		v.errorf(path, "does not have type "+jsonString(t))
+		return
+	}
+	if c, ok := s["const"]; ok && jsonString(c) != jsonString(doc) {
+		v.errorf(path, jsonString(doc)+" is not "+jsonString(c))
+	}
+	if n, ok := doc.(json.Number); ok {
+		f, _ := n.Float64()
+		if min, ok := s["minimum"].(float64); ok && f < min {
+			v.errorf(path, n.String()+" is below the minimum")
+		}
+		if max, ok := s["maximum"].(float64); ok && f > max {
+			v.errorf(path, n.String()+" is above the maximum")
+		}
+	}
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, ok := doc[r.(string)]; !ok {
+					v.errorf(path, "missing required "+r.(string))
+				}
+			}
+		}
+		for k, value := range doc {
+			if p, ok := props[k]; ok {
+				v.validate(path+"/"+k, p, value)
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					v.errorf(path, "unexpected property "+k)
+				}
+			case map[string]interface{}:
+				v.validate(path+"/"+k, ap, value)
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"]; ok {
+			for i, item := range doc {
+				v.validate(path+"/"+jsonString(i), items, item)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) hasType(t interface{}, doc interface{}) bool {
+	if types, ok := t.([]interface{}); ok {
+		for _, t := range types {
+			if v.hasType(t, doc) {
+				return true
+			}
+		}
+		return false
+	}
+	switch doc := doc.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case json.Number:
+		_, err := doc.Int64()
+		return t == "number" || (t == "integer" && err == nil)
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return false
+}
+
+func (v *schemaValidator) errorf(path, msg string) {
+	if path == "" {
+		path = "/"
+	}
+	v.errs = append(v.errs, path+": "+msg)
+}
+
+func jsonString(value interface{}) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
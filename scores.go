@@ -0,0 +1,91 @@
+package slackanalytics
+
+import (
+	"math"
+	"strings"
+)
+
+// ScoreScalingMethod documents how SummaryScores are computed;
+// it is included in the exported stats next to the scores
+const ScoreScalingMethod = "Percent fields are computed over all words of a stats bucket: " +
+	"clout = we + you - i words, tone = positive - negative emotion words and emojis, " +
+	"analytic = articles + prepositions - pronouns, auxiliary verbs, conjunctions, adverbs and negations, " +
+	"each as a percentage of words. Standardized fields map a percent onto 0-100 with " +
+	"100 * NormalCDF((percent - mean) / stddev) using ScoreNorms, so 50 is the norm mean."
+
+// ScoreNorm is the mean and standard deviation of a
+// percent score used to standardize it onto 0-100
+type ScoreNorm struct {
+	Mean   float64
+	StdDev float64
+}
+
+var (
+	// ScoreNorms holds the norms used to standardize each summary score;
+	// the defaults are rough and can be replaced with norms of a real corpus
+	ScoreNorms = map[string]ScoreNorm{
+		"clout":    {Mean: 0, StdDev: 5},
+		"tone":     {Mean: 0, StdDev: 5},
+		"analytic": {Mean: 0, StdDev: 15},
+	}
+)
+
+// SummaryScores holds LIWC-style summary variables, both as a
+// percentage of words and standardized onto a 0-100 scale
+type SummaryScores struct {
+	CloutPercent    float64 `json:"clout_percent"`
+	TonePercent     float64 `json:"tone_percent"`
+	AnalyticPercent float64 `json:"analytic_percent"`
+	Clout           float64 `json:"clout"`
+	Tone            float64 `json:"tone"`
+	Analytic        float64 `json:"analytic"`
+}
+
+// GetSummaryScores takes in a word count map (and optionally an emoji count
+// map feeding the tone) and returns its summary scores; see ScoreScalingMethod
+func GetSummaryScores(wordCountMap map[string]int, emojiCountMap map[string]int) (scores SummaryScores) {
+	var numWords, clout, tone, analytic float64
+	for word, count := range wordCountMap {
+		w := strings.ToLower(word)
+		c := float64(count)
+		numWords += c
+		if inList(w, IWords) {
+			clout -= c
+		} else if inList(w, YouWords) || inList(w, WeWords) {
+			clout += c
+		}
+		if inList(w, PosEmo) {
+			tone += c
+		} else if inList(w, NegEmo) {
+			tone -= c
+		}
+		if inList(w, Articles) || inList(w, Prepositions) {
+			analytic += c
+		} else if inList(w, PersonalPronouns) || inList(w, ImpersonalPronouns) || inList(w, AuxiliaryVerbs) || inList(w, Conjunctions) || inList(w, Adverbs) || inList(w, Negations) {
+			analytic -= c
+		}
+	}
+	for e, count := range emojiCountMap {
+		tone += float64(GetEmojiTone([]string{e}) * count)
+	}
+	if numWords == 0 {
+		return
+	}
+	scores.CloutPercent = 100 * clout / numWords
+	scores.TonePercent = 100 * tone / numWords
+	scores.AnalyticPercent = 100 * analytic / numWords
+	scores.Clout = standardizeScore(scores.CloutPercent, ScoreNorms["clout"])
+	scores.Tone = standardizeScore(scores.TonePercent, ScoreNorms["tone"])
+	scores.Analytic = standardizeScore(scores.AnalyticPercent, ScoreNorms["analytic"])
+	return
+}
+
+// standardizeScore maps a percent score onto 0-100
+// using the normal CDF of its z-score
+func standardizeScore(percent float64, norm ScoreNorm) float64 {
+	if norm.StdDev <= 0 {
+		return 50
+	}
+	z := (percent - norm.Mean) / norm.StdDev
+	return 100 * 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
@@ -0,0 +1,472 @@
+package slackanalytics
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// searchDateLayout is the date layout of before: and after: filters
+	searchDateLayout = "2006-01-02"
+)
+
+// SearchDoc is an indexed message
+type SearchDoc struct {
+	Channel   string `json:"channel"`
+	User      string `json:"user"`
+	UserName  string `json:"user_name"`
+	TimeStamp string `json:"ts"`
+	Text      string `json:"text"`
+}
+
+// Posting lists the positions of a word in an indexed message
+type Posting struct {
+	Doc       int   `json:"doc"`
+	Positions []int `json:"positions"`
+}
+
+// searchWordOptions normalizes the words of indexed messages and of
+// queries; code is kept so that identifiers and commands can be found
+var searchWordOptions = WordOptions{TrimSymbols: true, Lower: true, KeepCode: true}
+
+// SearchIndex is an inverted index from the words of messages
+// (see MessageToWordsWithOptions) to the messages and positions they
+// occur at; Source and SourceModTime record the data folder indexed
+// and its DataModTime so that a stale index can be rebuilt
+type SearchIndex struct {
+	Source        string               `json:"source"`
+	SourceModTime time.Time            `json:"source_mod_time"`
+	Docs          []SearchDoc          `json:"docs"`
+	Postings      map[string][]Posting `json:"postings"`
+}
+
+// SearchFilters restrict search results to messages from any of the users
+// in From (ids or names), in any of the channels in In, and sent in
+// [After, Before); zero values do not restrict
+type SearchFilters struct {
+	From   []string
+	In     []string
+	Before time.Time
+	After  time.Time
+}
+
+// SearchQuery is a parsed search query: a boolean
+// expression over words and phrases plus filters
+type SearchQuery struct {
+	Filters SearchFilters
+	expr    *queryNode
+}
+
+// queryNode is a node of a boolean query expression; leaves
+// hold the words of a phrase (a single word for plain terms)
+type queryNode struct {
+	op       string
+	words    []string
+	children []*queryNode
+}
+
+// BuildSearchIndex indexes the words of messages; users are used to
+// store the display name of each author so that from: can match it
+func BuildSearchIndex(messages []Message, users []*User) (idx *SearchIndex) {
+	idx = &SearchIndex{
+		Docs:     []SearchDoc{},
+		Postings: make(map[string][]Posting),
+	}
+	names := make(map[string]string)
+	for _, u := range users {
+		names[u.Id] = GetUserName(u)
+	}
+	for _, m := range messages {
+		if m.Text == "" {
+			continue
+		}
+		doc := len(idx.Docs)
+		idx.Docs = append(idx.Docs, SearchDoc{
+			Channel:   m.Channel,
+			User:      m.User,
+			UserName:  names[m.User],
+			TimeStamp: m.TimeStamp,
+			Text:      m.Text,
+		})
+		for pos, w := range MessageToWordsWithOptions(m, searchWordOptions) {
+			postings := idx.Postings[w]
+			if len(postings) == 0 || postings[len(postings)-1].Doc != doc {
+				postings = append(postings, Posting{Doc: doc})
+			}
+			postings[len(postings)-1].Positions = append(postings[len(postings)-1].Positions, pos)
+			idx.Postings[w] = postings
+		}
+	}
+	return
+}
+
+// SaveSearchIndex writes a search index to a JSON file
+func SaveSearchIndex(idx *SearchIndex, filePath string) (err error) {
+	indexBytes, err := json.Marshal(idx)
+	if err != nil {
+		return
+	}
+	err = ioutil.WriteFile(filePath, indexBytes, 0644)
+	return
+}
+
+// LoadSearchIndex reads a search index written by SaveSearchIndex
+func LoadSearchIndex(filePath string) (idx *SearchIndex, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	indexBytes, err := ioutil.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(indexBytes, &idx)
+	return
+}
+
+// DataModTime returns the latest modification time of the users and
+// channels files, the channel folders and the files in them
+func DataModTime(dataPath string) (modTime time.Time, err error) {
+	fileInfos, err := ioutil.ReadDir(dataPath)
+	if err != nil {
+		return
+	}
+	for _, f := range fileInfos {
+		if !f.IsDir() {
+			if f.Name() == "users.json" || f.Name() == "channels.json" {
+				modTime = latestTime(modTime, f.ModTime())
+			}
+			continue
+		}
+		modTime = latestTime(modTime, f.ModTime())
+		var dayInfos []os.FileInfo
+		dayInfos, err = ioutil.ReadDir(filepath.Join(dataPath, f.Name()))
+		if err != nil {
+			return
+		}
+		for _, d := range dayInfos {
+			modTime = latestTime(modTime, d.ModTime())
+		}
+	}
+	return
+}
+
+// IsSearchIndexStale determines whether an index was built from another
+// data folder than source or before the data last changed (modTime)
+func IsSearchIndexStale(idx *SearchIndex, source string, modTime time.Time) bool {
+	return idx.Source != source || !idx.SourceModTime.Equal(modTime)
+}
+
+func latestTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// ParseSearchQuery parses a query made of words, "quoted phrases",
+// AND (implied between terms), OR, NOT or a leading -, parentheses,
+// and the filters from:user in:channel before:YYYY-MM-DD after:YYYY-MM-DD
+func ParseSearchQuery(query string) (q SearchQuery, err error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return
+	}
+	terms := []string{}
+	for _, t := range tokens {
+		key, value, isFilter := strings.Cut(t, ":")
+		if !isFilter || value == "" || strings.HasPrefix(t, "\"") {
+			terms = append(terms, t)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "from":
+			q.Filters.From = append(q.Filters.From, strings.TrimPrefix(value, "@"))
+		case "in":
+			q.Filters.In = append(q.Filters.In, strings.TrimPrefix(value, "#"))
+		case "before":
+			q.Filters.Before, err = time.ParseInLocation(searchDateLayout, value, time.Local)
+		case "after":
+			q.Filters.After, err = time.ParseInLocation(searchDateLayout, value, time.Local)
+		default:
+			terms = append(terms, t)
+		}
+		if err != nil {
+			return
+		}
+	}
+	p := queryParser{tokens: terms}
+	if len(terms) > 0 {
+		q.expr, err = p.parseOr()
+		if err == nil && p.pos < len(p.tokens) {
+			err = errors.New("unexpected " + p.tokens[p.pos] + " in query")
+		}
+	}
+	return
+}
+
+// Search runs a query against an index and returns
+// the matching messages, most recent first
+func Search(idx *SearchIndex, q SearchQuery) (results []SearchDoc) {
+	results = []SearchDoc{}
+	var docs map[int]bool
+	if q.expr != nil {
+		docs = idx.eval(q.expr)
+	}
+	for i, d := range idx.Docs {
+		if q.expr != nil && !docs[i] {
+			continue
+		}
+		if !q.Filters.match(d) {
+			continue
+		}
+		results = append(results, d)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return timeStampSeconds(results[i].TimeStamp) > timeStampSeconds(results[j].TimeStamp)
+	})
+	return
+}
+
+// match reports whether an indexed message passes the filters
+func (f SearchFilters) match(d SearchDoc) bool {
+	if len(f.From) > 0 {
+		found := false
+		for _, from := range f.From {
+			if strings.EqualFold(from, d.User) || strings.EqualFold(from, d.UserName) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.In) > 0 {
+		found := false
+		for _, in := range f.In {
+			if strings.EqualFold(in, d.Channel) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	t := messageTime(Message{TimeStamp: d.TimeStamp})
+	if !f.Before.IsZero() && !t.Before(f.Before) {
+		return false
+	}
+	if !f.After.IsZero() && t.Before(f.After) {
+		return false
+	}
+	return true
+}
+
+// eval returns the set of documents matching a query expression
+func (idx *SearchIndex) eval(n *queryNode) (docs map[int]bool) {
+	docs = make(map[int]bool)
+	switch n.op {
+	case "and":
+		for i, c := range n.children {
+			childDocs := idx.eval(c)
+			if i == 0 {
+				docs = childDocs
+				continue
+			}
+			for d := range docs {
+				if !childDocs[d] {
+					delete(docs, d)
+				}
+			}
+		}
+	case "or":
+		for _, c := range n.children {
+			for d := range idx.eval(c) {
+				docs[d] = true
+			}
+		}
+	case "not":
+		childDocs := idx.eval(n.children[0])
+		for d := range idx.Docs {
+			if !childDocs[d] {
+				docs[d] = true
+			}
+		}
+	default:
+		for _, d := range idx.phraseDocs(n.words) {
+			docs[d] = true
+		}
+	}
+	return
+}
+
+// phraseDocs returns the documents holding
+// the words of a phrase next to each other
+func (idx *SearchIndex) phraseDocs(words []string) (docs []int) {
+	if len(words) == 0 {
+		return
+	}
+	positions := make([]map[int][]int, len(words))
+	for i, w := range words {
+		positions[i] = make(map[int][]int)
+		for _, p := range idx.Postings[w] {
+			positions[i][p.Doc] = p.Positions
+		}
+	}
+	for _, p := range idx.Postings[words[0]] {
+		for _, start := range p.Positions {
+			found := true
+			for i := 1; i < len(words) && found; i++ {
+				found = containsInt(positions[i][p.Doc], start+i)
+			}
+			if found {
+				docs = append(docs, p.Doc)
+				break
+			}
+		}
+	}
+	return
+}
+
+// queryParser is a recursive descent parser over query tokens
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *queryParser) parseOr() (n *queryNode, err error) {
+	n, err = p.parseAnd()
+	if err != nil {
+		return
+	}
+	or := &queryNode{op: "or", children: []*queryNode{n}}
+	for p.pos < len(p.tokens) && p.tokens[p.pos] == "OR" {
+		p.pos += 1
+		var c *queryNode
+		c, err = p.parseAnd()
+		if err != nil {
+			return
+		}
+		or.children = append(or.children, c)
+	}
+	if len(or.children) > 1 {
+		n = or
+	}
+	return
+}
+
+func (p *queryParser) parseAnd() (n *queryNode, err error) {
+	and := &queryNode{op: "and"}
+	for p.pos < len(p.tokens) && p.tokens[p.pos] != "OR" && p.tokens[p.pos] != ")" {
+		if p.tokens[p.pos] == "AND" {
+			p.pos += 1
+			continue
+		}
+		var c *queryNode
+		c, err = p.parseUnary()
+		if err != nil {
+			return
+		}
+		if c != nil {
+			and.children = append(and.children, c)
+		}
+	}
+	switch len(and.children) {
+	case 0:
+		err = errors.New("empty query expression")
+	case 1:
+		n = and.children[0]
+	default:
+		n = and
+	}
+	return
+}
+
+func (p *queryParser) parseUnary() (n *queryNode, err error) {
+	t := p.tokens[p.pos]
+	p.pos += 1
+	switch {
+	case t == "NOT" || t == "-":
+		if p.pos >= len(p.tokens) {
+			return nil, errors.New("missing term after " + t)
+		}
+		var c *queryNode
+		c, err = p.parseUnary()
+		if err != nil || c == nil {
+			return
+		}
+		return &queryNode{op: "not", children: []*queryNode{c}}, nil
+	case t == "(":
+		n, err = p.parseOr()
+		if err != nil {
+			return
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, errors.New("missing ) in query")
+		}
+		p.pos += 1
+		return
+	}
+	words := MessageToWordsWithOptions(Message{Text: strings.Trim(t, "\"")}, searchWordOptions)
+	if len(words) == 0 {
+		return
+	}
+	return &queryNode{words: words}, nil
+}
+
+// tokenizeQuery splits a query into words, quoted phrases
+// (kept with their quotes), parentheses and leading dashes
+func tokenizeQuery(query string) (tokens []string, err error) {
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	inQuotes := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			current.WriteRune(r)
+			if inQuotes {
+				flush()
+			}
+			inQuotes = !inQuotes
+		case inQuotes:
+			current.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == '-' && current.Len() == 0:
+			tokens = append(tokens, "-")
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unterminated quote in query")
+	}
+	flush()
+	return
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package slackanalytics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchFindsCode(t *testing.T) {
+	idx := BuildSearchIndex([]Message{{User: "U1", Text: "try `kubectl rollout restart`", TimeStamp: "1600000000.000100"}}, nil)
+	q, err := ParseSearchQuery("kubectl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results := Search(idx, q); len(results) != 1 {
+		t.Errorf("got %d results for a word in inline code, want 1", len(results))
+	}
+}
+
+func TestIsSearchIndexStale(t *testing.T) {
+	built := time.Unix(1600000000, 0)
+	idx := &SearchIndex{Source: "/data", SourceModTime: built}
+	if IsSearchIndexStale(idx, "/data", built) {
+		t.Error("index of unchanged data is stale")
+	}
+	if !IsSearchIndexStale(idx, "/other", built) {
+		t.Error("index of another folder is not stale")
+	}
+	if !IsSearchIndexStale(idx, "/data", built.Add(time.Second)) {
+		t.Error("index of changed data is not stale")
+	}
+}
@@ -0,0 +1,286 @@
+package slackanalytics
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// serverTopAmount is the default number of top words served
+	serverTopAmount = 20
+	// serverSearchAmount is the default number of search results served
+	serverSearchAmount = 50
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// Server serves stats loaded once as a JSON API under /api/ along
+// with the bundled static dashboard; it needs no network access
+//
+//	GET /api/overview                     overview numbers
+//	GET /api/users, /api/users/{id}       user summaries, full user stats
+//	GET /api/channels, /api/channels/{n}  channel summaries, full channel stats
+//	GET /api/periods/{day|month}[/{key}]  period summaries, full period stats
+//	GET /api/words?kind=&key=&n=          top words of the workspace or a group
+//	GET /api/search?q=&n=                 messages matching a search query
+//	GET /api/graphs/{activity|tone|categories}?period=
+//	                                      series for the dashboard charts
+//	GET /api/schema                       JSON Schema of the stats
+type Server struct {
+	Stats  SlackMessageStats
+	Report Report
+	Index  *SearchIndex
+	mux    *http.ServeMux
+}
+
+// StatsSummary is a group of stats reduced to its scalar metrics
+// keyed by the names of StatsColumns
+type StatsSummary struct {
+	Key     string             `json:"key"`
+	Name    string             `json:"name,omitempty"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// GraphPoint is a point of a chart series
+type GraphPoint struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+}
+
+// NewServer returns a server for message stats; users name the users
+// and idx, which may be nil, answers search queries
+func NewServer(s SlackMessageStats, users []*User, idx *SearchIndex) *Server {
+	srv := &Server{
+		Stats:  s,
+		Report: BuildReport(s, users, serverTopAmount),
+		Index:  idx,
+		mux:    http.NewServeMux(),
+	}
+	static, _ := fs.Sub(staticFS, "static")
+	srv.mux.Handle("/", http.FileServer(http.FS(static)))
+	srv.mux.HandleFunc("/api/", srv.serveAPI)
+	return srv
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// serveAPI routes the requests under /api/
+func (srv *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+	switch {
+	case parts[0] == "overview" && len(parts) == 1:
+		srv.serveOverview(w)
+	case parts[0] == "users":
+		srv.serveGroup(w, parts[1:], srv.Report.Users)
+	case parts[0] == "channels":
+		srv.serveGroup(w, parts[1:], srv.Report.Channels)
+	case parts[0] == "periods" && len(parts) >= 2:
+		periods, ok := srv.periods(parts[1])
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "unknown period "+parts[1])
+			return
+		}
+		rows := make([]ReportRow, len(periods))
+		for i, p := range periods {
+			rows[i] = ReportRow{Key: p.Period, Stats: p.Stats}
+		}
+		srv.serveGroup(w, parts[2:], rows)
+	case parts[0] == "words" && len(parts) == 1:
+		srv.serveWords(w, r)
+	case parts[0] == "search" && len(parts) == 1:
+		srv.serveSearch(w, r)
+	case parts[0] == "graphs" && len(parts) == 2:
+		srv.serveGraph(w, r, parts[1])
+	case parts[0] == "schema" && len(parts) == 1:
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(StatsJSONSchema)
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (srv *Server) serveOverview(w http.ResponseWriter) {
+	r := srv.Report
+	writeJSON(w, map[string]interface{}{
+		"title":        r.Title,
+		"generated":    r.Generated.Unix(),
+		"first_day":    r.FirstDay,
+		"last_day":     r.LastDay,
+		"num_users":    r.NumUsers,
+		"num_channels": r.NumChannels,
+		"metrics":      GetStatsSummary("", r.Overview).Metrics,
+		"top_emojis":   r.TopEmojis,
+		"categories":   r.Categories,
+	})
+}
+
+// serveGroup serves the summaries of a group of stats,
+// or the full stats of one of them if a key is given
+func (srv *Server) serveGroup(w http.ResponseWriter, rest []string, rows []ReportRow) {
+	if len(rest) == 0 {
+		summaries := []StatsSummary{}
+		for _, row := range rows {
+			summary := GetStatsSummary(row.Key, row.Stats)
+			if row.Name != row.Key {
+				summary.Name = row.Name
+			}
+			summaries = append(summaries, summary)
+		}
+		writeJSON(w, summaries)
+		return
+	}
+	if len(rest) == 1 {
+		for _, row := range rows {
+			if row.Key == rest[0] {
+				writeJSON(w, row.Stats)
+				return
+			}
+		}
+	}
+	writeJSONError(w, http.StatusNotFound, "not found")
+}
+
+func (srv *Server) serveWords(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	amount := queryInt(q.Get("n"), serverTopAmount)
+	ms, ok := srv.group(q.Get("kind"), q.Get("key"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "unknown "+q.Get("kind")+" "+q.Get("key"))
+		return
+	}
+	writeJSON(w, GetTopReportWords(ms, amount))
+}
+
+func (srv *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if srv.Index == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "search is not available for these stats")
+		return
+	}
+	q, err := ParseSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results := Search(srv.Index, q)
+	amount := queryInt(r.URL.Query().Get("n"), serverSearchAmount)
+	if len(results) > amount {
+		results = results[:amount]
+	}
+	writeJSON(w, results)
+}
+
+// serveGraph serves a chart series: messages per period (activity),
+// tone per period (tone) or overall category counts (categories)
+func (srv *Server) serveGraph(w http.ResponseWriter, r *http.Request, graph string) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "day"
+	}
+	points := []GraphPoint{}
+	switch graph {
+	case "activity", "tone":
+		periods, ok := srv.periods(period)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "unknown period "+period)
+			return
+		}
+		for _, p := range periods {
+			value := float64(p.Stats.NumMessages)
+			if graph == "tone" {
+				value = p.Stats.Scores.Tone
+			}
+			points = append(points, GraphPoint{Label: p.Period, Value: value})
+		}
+	case "categories":
+		for _, c := range srv.Report.Categories {
+			points = append(points, GraphPoint{Label: c.Word, Value: float64(c.Count)})
+		}
+	default:
+		writeJSONError(w, http.StatusNotFound, "unknown graph "+graph)
+		return
+	}
+	writeJSON(w, points)
+}
+
+// periods returns the daily or monthly stats
+func (srv *Server) periods(period string) ([]ReportPeriod, bool) {
+	switch period {
+	case "day":
+		return srv.Report.Daily, true
+	case "month":
+		return srv.Report.Monthly, true
+	}
+	return nil, false
+}
+
+// group returns the stats of a user, channel, day or
+// month, or the overall stats if kind is empty
+func (srv *Server) group(kind, key string) (ms *MessageStats, ok bool) {
+	var stats map[string]*MessageStats
+	switch kind {
+	case "", "all":
+		return srv.Report.Overview, true
+	case "user":
+		stats = srv.Stats.UserStats
+	case "channel":
+		stats = srv.Stats.ChannelStats
+	case "day":
+		stats = srv.Stats.DailyStats
+	case "month":
+		stats = srv.Stats.MonthlyStats
+	}
+	ms, ok = stats[key]
+	return
+}
+
+// GetStatsSummary reduces message stats to their scalar metrics
+func GetStatsSummary(key string, ms *MessageStats) StatsSummary {
+	summary := StatsSummary{Key: key, Metrics: make(map[string]float64)}
+	for i, v := range statsValues(ms) {
+		switch v := v.(type) {
+		case int:
+			summary.Metrics[StatsColumns[i]] = float64(v)
+		case float64:
+			summary.Metrics[StatsColumns[i]] = v
+		}
+	}
+	return summary
+}
+
+// writeJSON encodes v before writing anything, so that
+// a value that cannot be encoded is answered with a 500
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// queryInt parses a positive query parameter, falling back to def
+func queryInt(s string, def int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
@@ -2,6 +2,7 @@ package slackanalytics
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -9,51 +10,82 @@ import (
 )
 
 type SlackStats struct {
-	AllStats     *WordStats
-	UserStats    map[string]*WordStats
-	ChannelStats map[string]*WordStats
+	ScoreScaling  string                `json:"score_scaling"`
+	AllStats      *WordStats            `json:"all_stats"`
+	UserStats     map[string]*WordStats `json:"user_stats"`
+	ChannelStats  map[string]*WordStats `json:"channel_stats"`
+	LanguageStats map[string]*WordStats `json:"language_stats"`
 }
 
 type SlackMessageStats struct {
-	Time         int
-	AllStats     *MessageStats
-	UserStats    map[string]*MessageStats
-	DailyStats   map[string]*MessageStats
-	MonthlyStats map[string]*MessageStats
+	SchemaVersion int                      `json:"schema_version"`
+	Time          int                      `json:"time"`
+	ScoreScaling  string                   `json:"score_scaling"`
+	AllStats      *MessageStats            `json:"all_stats"`
+	UserStats     map[string]*MessageStats `json:"user_stats"`
+	ChannelStats  map[string]*MessageStats `json:"channel_stats"`
+	DailyStats    map[string]*MessageStats `json:"daily_stats"`
+	MonthlyStats  map[string]*MessageStats `json:"monthly_stats"`
+	LanguageStats map[string]*MessageStats `json:"language_stats"`
+	Topics        *TopicModel              `json:"topics,omitempty"`
+	Alerts        *AlertReport             `json:"alerts,omitempty"`
 }
 
 type MessageStats struct {
-	NumMessages       int
-	NumWords          int
-	NumEmojis         int
-	TotalTextLength   int
-	AvgWordLength     float64
-	AvgWordsPerMsg    float64
-	AvgEmojisPerMsg   float64
-	AvgCloutPerMsg    float64
-	AvgTonePerMsg     float64
-	AvgAnalyticPerMsg float64
-	WordCountMap      map[string]int
-	EmojiCountMap     map[string]int
-	CategoryCounts    map[string]int
+	NumMessages       int                `json:"num_messages"`
+	NumScoredMessages int                `json:"num_scored_messages"`
+	NumWords          int                `json:"num_words"`
+	NumEmojis         int                `json:"num_emojis"`
+	TotalTextLength   int                `json:"total_text_length"`
+	AvgWordLength     float64            `json:"avg_word_length"`
+	AvgWordsPerMsg    float64            `json:"avg_words_per_msg"`
+	AvgEmojisPerMsg   float64            `json:"avg_emojis_per_msg"`
+	AvgCloutPerMsg    float64            `json:"avg_clout_per_msg"`
+	AvgTonePerMsg     float64            `json:"avg_tone_per_msg"`
+	AvgAnalyticPerMsg float64            `json:"avg_analytic_per_msg"`
+	Scores            SummaryScores      `json:"scores"`
+	Readability       Readability        `json:"readability"`
+	Code              CodeStats          `json:"code"`
+	Links             LinkStats          `json:"links"`
+	WordCountMap      map[string]int     `json:"word_count_map"`
+	BigramCountMap    map[string]int     `json:"bigram_count_map"`
+	TrigramCountMap   map[string]int     `json:"trigram_count_map"`
+	EmojiCountMap     map[string]int     `json:"emoji_count_map"`
+	CategoryCounts    map[string]int     `json:"category_counts"`
+	EmotionCounts     map[string]int     `json:"emotion_counts"`
+	EmotionShares     map[string]float64 `json:"emotion_shares"`
+	scoredWordCounts  map[string]int
+	stopwordCounts    map[string]int
 }
 
 type WordStats struct {
-	TotalTextLength   int
-	TotalWords        int
-	TotalMessages     int
-	AvgWordLength     float64
-	AvgWordsPerMsg    float64
-	AvgCloutPerMsg    float64
-	AvgTonePerMsg     float64
-	AvgAnalyticPerMsg float64
-	WordCountMap      map[string]int
-	CategoryCounts    map[string]int
+	TotalTextLength   int                `json:"total_text_length"`
+	TotalWords        int                `json:"total_words"`
+	TotalMessages     int                `json:"total_messages"`
+	TotalScoredMsgs   int                `json:"total_scored_msgs"`
+	AvgWordLength     float64            `json:"avg_word_length"`
+	AvgWordsPerMsg    float64            `json:"avg_words_per_msg"`
+	AvgCloutPerMsg    float64            `json:"avg_clout_per_msg"`
+	AvgTonePerMsg     float64            `json:"avg_tone_per_msg"`
+	AvgAnalyticPerMsg float64            `json:"avg_analytic_per_msg"`
+	Scores            SummaryScores      `json:"scores"`
+	Readability       Readability        `json:"readability"`
+	Code              CodeStats          `json:"code"`
+	Links             LinkStats          `json:"links"`
+	WordCountMap      map[string]int     `json:"word_count_map"`
+	BigramCountMap    map[string]int     `json:"bigram_count_map"`
+	TrigramCountMap   map[string]int     `json:"trigram_count_map"`
+	CategoryCounts    map[string]int     `json:"category_counts"`
+	EmotionCounts     map[string]int     `json:"emotion_counts"`
+	EmotionShares     map[string]float64 `json:"emotion_shares"`
+	scoredWordCounts  map[string]int
+	stopwordCounts    map[string]int
+	emojiCounts       map[string]int
 }
 
 type WordCount struct {
-	Word  string
-	Count int
+	Word  string `json:"word"`
+	Count int    `json:"count"`
 }
 
 type sortByCount []WordCount
@@ -73,11 +105,12 @@ func (s sortByCount) Swap(i, j int) {
 // GetSlackStats takes in a slice of users and channels and calculates the
 // total # of words, avg word length, frequency counts, and sentiment analysis.
 func GetSlackStats(users []*User, channels []*Channel) (ss SlackStats) {
-	SortCategories()
 	ss = SlackStats{
-		AllStats:     newWordStats(),
-		UserStats:    make(map[string]*WordStats),
-		ChannelStats: make(map[string]*WordStats),
+		ScoreScaling:  ScoreScalingMethod,
+		AllStats:      newWordStats(),
+		UserStats:     make(map[string]*WordStats),
+		ChannelStats:  make(map[string]*WordStats),
+		LanguageStats: make(map[string]*WordStats),
 	}
 	// init user stats
 	for _, u := range users {
@@ -87,77 +120,37 @@ func GetSlackStats(users []*User, channels []*Channel) (ss SlackStats) {
 	for _, c := range channels {
 		ss.ChannelStats[c.Id] = newWordStats()
 	}
-	var words []string
-	var clout float64
-	var tone float64
-	var analytic float64
 	for _, c := range channels {
 		for _, m := range c.Messages {
 			if m.Text == "" {
 				continue
 			}
-			words = MessageToWords(m, true, true)
-			clout = float64(GetClout(words))
-			tone = float64(GetTone(words))
-			analytic = float64(GetAnalytic(words))
-			ss.AllStats.TotalTextLength += len(m.Text)
-			ss.AllStats.TotalMessages += 1
-			ss.AllStats.AvgCloutPerMsg += clout
-			ss.AllStats.AvgTonePerMsg += tone
-			ss.AllStats.AvgAnalyticPerMsg += analytic
-			userStats, userOk := ss.UserStats[m.User]
-			if userOk {
-				userStats.TotalTextLength += len(m.Text)
-				userStats.TotalMessages += 1
-				userStats.AvgCloutPerMsg += clout
-				userStats.AvgTonePerMsg += tone
-				userStats.AvgAnalyticPerMsg += analytic
+			pm := parseMessage(m)
+			ss.AllStats.addMessage(pm)
+			if userStats, ok := ss.UserStats[m.User]; ok {
+				userStats.addMessage(pm)
 			}
-			channelStats, channelOk := ss.ChannelStats[c.Name]
-			if channelOk {
-				channelStats.TotalTextLength += len(m.Text)
-				channelStats.TotalMessages += 1
-				channelStats.AvgCloutPerMsg += clout
-				channelStats.AvgTonePerMsg += tone
-				channelStats.AvgAnalyticPerMsg += analytic
+			if channelStats, ok := ss.ChannelStats[c.Id]; ok {
+				channelStats.addMessage(pm)
 			}
-			for _, w := range words {
-				if w == "" {
-					continue
-				}
-				l := float64(len(w))
-				ss.AllStats.TotalWords += 1
-				ss.AllStats.AvgWordLength += l
-				updateWordCountMap(w, &ss.AllStats.WordCountMap)
-				if userOk {
-					userStats.TotalWords += 1
-					userStats.AvgWordLength += l
-					updateWordCountMap(w, &userStats.WordCountMap)
-				}
-				if channelOk {
-					channelStats.TotalWords += 1
-					channelStats.AvgWordLength += l
-					updateWordCountMap(w, &channelStats.WordCountMap)
-				}
+			languageStats, ok := ss.LanguageStats[pm.language]
+			if !ok {
+				languageStats = newWordStats()
+				ss.LanguageStats[pm.language] = languageStats
 			}
+			languageStats.addMessage(pm)
 		}
 	}
 	wordCategoriesCache := make(map[string][]string)
 	populateCategoryCounts(ss.AllStats, &wordCategoriesCache)
 	setAverages(ss.AllStats)
-	for _, ws := range ss.UserStats {
-		if ws.TotalWords == 0 {
-			continue
-		}
-		populateCategoryCounts(ws, &wordCategoriesCache)
-		setAverages(ws)
-	}
-	for _, ws := range ss.ChannelStats {
-		if ws.TotalWords == 0 {
-			continue
+	ss.AllStats.Links.setLinkShare(ss.AllStats.Links.NumLinks)
+	for _, group := range []map[string]*WordStats{ss.UserStats, ss.ChannelStats, ss.LanguageStats} {
+		for _, ws := range group {
+			ws.Links.setLinkShare(ss.AllStats.Links.NumLinks)
+			populateCategoryCounts(ws, &wordCategoriesCache)
+			setAverages(ws)
 		}
-		populateCategoryCounts(ws, &wordCategoriesCache)
-		setAverages(ws)
 	}
 	return
 }
@@ -177,8 +170,18 @@ func GetSortedWords(ws *WordStats) (wordCounts []WordCount) {
 
 // GetTopWords takes in a slice of words/frequencies (sorted by frequency) and
 // returns the top amount of words of highest frequency, skipping common ones
-// if includeCommon is false
+// (English stopwords) if includeCommon is false
 func GetTopWords(wordCounts []WordCount, amount int, includeCommon bool) (topWordCounts []WordCount) {
+	return getTopWords(wordCounts, amount, includeCommon, IsStopword)
+}
+
+// GetTopStatsWords is GetTopWords for the words of word stats, skipping
+// the words used as stopwords of the language of their messages
+func GetTopStatsWords(ws *WordStats, amount int, includeCommon bool) []WordCount {
+	return getTopWords(GetSortedWords(ws), amount, includeCommon, ws.isStopword)
+}
+
+func getTopWords(wordCounts []WordCount, amount int, includeCommon bool, isStopword func(string) bool) (topWordCounts []WordCount) {
 	topWordCounts = make([]WordCount, amount)
 	i := 0
 	j := 0
@@ -186,16 +189,7 @@ func GetTopWords(wordCounts []WordCount, amount int, includeCommon bool) (topWor
 		wc := wordCounts[i]
 		i += 1
 		w := wc.Word
-		isCommon := false
-		if !includeCommon {
-			for _, c := range CommonWords {
-				if c == strings.ToLower(w) {
-					isCommon = true
-					continue
-				}
-			}
-		}
-		if isCommon {
+		if !includeCommon && isStopword(w) {
 			continue
 		}
 		topWordCounts[j] = wc
@@ -204,6 +198,25 @@ func GetTopWords(wordCounts []WordCount, amount int, includeCommon bool) (topWor
 	return
 }
 
+// isStopword determines whether a word was mostly used as a stopword of
+// the language of its messages; stats loaded from JSON, which do not hold
+// the languages of words, fall back to the English list
+func (ws *WordStats) isStopword(w string) bool {
+	return isMostlyStopword(w, ws.WordCountMap, ws.stopwordCounts)
+}
+
+// isStopword is WordStats.isStopword for message stats
+func (ms *MessageStats) isStopword(w string) bool {
+	return isMostlyStopword(w, ms.WordCountMap, ms.stopwordCounts)
+}
+
+func isMostlyStopword(w string, wordCounts, stopwordCounts map[string]int) bool {
+	if stopwordCounts == nil {
+		return IsStopword(w)
+	}
+	return stopwordCounts[w] > 0 && 2*stopwordCounts[w] >= wordCounts[w]
+}
+
 // GetClout loosely calculates the clout of a
 // slice of words (+1 for we/you and -1 for i)
 func GetClout(words []string) (clout int) {
@@ -266,54 +279,94 @@ func GetCategories(word string) (categories []string) {
 }
 
 // GetAndPrintStats takes in a slice of users and
-// slice of channels, and prints some stats about them
-func GetAndPrintStats(users []*User, channels []*Channel) {
-	ss := GetSlackStats(users, channels)
-	wordCounts := GetSortedWords(ss.AllStats)
-	topWords := GetTopWords(wordCounts, 0, false)
-	printStats(ss.AllStats)
-	fmt.Println("Category counts:")
-	fmt.Println(ss.AllStats.CategoryCounts)
-	fmt.Println()
-	for _, wc := range topWords {
-		fmt.Println(wc.Word + " " + strconv.Itoa(wc.Count))
+// slice of channels, prints some stats about them and returns the stats
+func GetAndPrintStats(users []*User, channels []*Channel) (ss SlackStats) {
+	return GetAndPrintStatsWithOptions(users, channels, DefaultTerminalOptions())
+}
+
+// GetAndPrintStatsWithOptions is GetAndPrintStats printing aligned tables,
+// activity charts and a message length histogram as configured by opt
+func GetAndPrintStatsWithOptions(users []*User, channels []*Channel, opt TerminalOptions) (ss SlackStats) {
+	if opt.Writer == nil {
+		opt.Writer = os.Stdout
+	}
+	if opt.Width <= 0 {
+		opt.Width = DefaultTerminalOptions().Width
+	}
+	w := opt.Writer
+	ss = GetSlackStats(users, channels)
+	topWords := GetTopStatsWords(ss.AllStats, opt.TopWords, false)
+	var messages []Message
+	for _, c := range channels {
+		messages = append(messages, c.Messages...)
+	}
+	WriteStatsTable(w, ss.AllStats)
+	fmt.Fprintln(w)
+	WriteActivityCharts(w, messages, opt.Width, opt.Fancy)
+	fmt.Fprintln(w)
+	WriteLengthHistogram(w, messages, opt.Width, opt.Fancy)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Category counts:")
+	WriteCountChart(w, ss.AllStats.CategoryCounts, 0, opt.Width, opt.Fancy)
+	fmt.Fprintln(w)
+	if ss.AllStats.Code.NumCodeMessages > 0 {
+		WriteCodeStats(w, ss.AllStats.Code, opt.Width, opt.Fancy)
+		fmt.Fprintln(w)
+	}
+	if ss.AllStats.Links.NumLinks > 0 {
+		WriteLinkStats(w, ss.AllStats.Links, opt.Width, opt.Fancy)
+		fmt.Fprintln(w)
 	}
+	if len(topWords) > 0 {
+		labels, values := []string{}, []float64{}
+		for _, wc := range topWords {
+			if wc.Word == "" {
+				continue
+			}
+			labels = append(labels, wc.Word)
+			values = append(values, float64(wc.Count))
+		}
+		fmt.Fprintln(w, "Top words:")
+		WriteBarChart(w, labels, values, opt.Width, opt.Fancy)
+		fmt.Fprintln(w)
+	}
+	distinctive := GetDistinctiveWords(ss.UserStats, DistinctLogOdds, 10, false)
 	for _, u := range users {
 		if u.Deleted {
 			continue
 		}
 		ws, ok := ss.UserStats[u.Id]
-		if !ok || ws.TotalWords == 0 {
+		if !ok || ws.TotalMessages == 0 {
 			continue
 		}
-		name := u.Profile.RealName
-		if u.Profile.DisplayName != "" {
-			name = u.Profile.DisplayName
+		fmt.Fprintln(w, GetUserName(u)+"\n")
+		WriteStatsTable(w, ws)
+		fmt.Fprint(w, "Distinctive words:")
+		for _, dw := range distinctive[u.Id] {
+			fmt.Fprint(w, " "+dw.Word)
 		}
-		fmt.Println(name + "\n")
-		printStats(ws)
-		fmt.Println()
+		fmt.Fprintln(w)
+		fmt.Fprintln(w)
 	}
+	return
 }
 
 // AnalyzeMessages uses messages to get statistics on a per-user basis,
-// per-day basis, and per-month basis. Overall stats are also included.
+// per-channel basis, per-day basis, per-month basis and per-language basis. Overall stats are
+// also included.
 func AnalyzeMessages(messages []Message) (s SlackMessageStats) {
-	SortCategories()
 	s = SlackMessageStats{
-		Time:         int(time.Now().Unix()),
-		AllStats:     newMessageStats(),
-		UserStats:    make(map[string]*MessageStats),
-		DailyStats:   make(map[string]*MessageStats),
-		MonthlyStats: make(map[string]*MessageStats),
+		SchemaVersion: StatsSchemaVersion,
+		Time:          int(time.Now().Unix()),
+		ScoreScaling:  ScoreScalingMethod,
+		AllStats:      newMessageStats(),
+		UserStats:     make(map[string]*MessageStats),
+		ChannelStats:  make(map[string]*MessageStats),
+		DailyStats:    make(map[string]*MessageStats),
+		MonthlyStats:  make(map[string]*MessageStats),
+		LanguageStats: make(map[string]*MessageStats),
 	}
 
-	var words []string
-	var emojis []string
-	var clout float64
-	var tone float64
-	var analytic float64
-
 	for _, m := range messages {
 		if m.Text == "" {
 			continue
@@ -328,131 +381,37 @@ func AnalyzeMessages(messages []Message) (s SlackMessageStats) {
 		tm := time.Unix(t, 0)
 		d := tm.Format("2006-01-02")
 		mo := tm.Format("2006-01")
-		u := m.User
-
-		words, emojis = ParseWords(m, false)
-		clout = float64(GetClout(words))
-		tone = float64(GetTone(words))
-		analytic = float64(GetAnalytic(words))
-
-		s.AllStats.TotalTextLength += len(m.Text)
-		s.AllStats.NumMessages += 1
-		s.AllStats.NumWords += len(words)
-		s.AllStats.NumEmojis += len(emojis)
-		s.AllStats.AvgCloutPerMsg += clout
-		s.AllStats.AvgTonePerMsg += tone
-		s.AllStats.AvgAnalyticPerMsg += analytic
-
-		userStats, userOk := s.UserStats[u]
-		if !userOk {
-			userStats = newMessageStats()
-			s.UserStats[u] = userStats
-		}
-		dailyStats, dailyOk := s.DailyStats[d]
-		if !dailyOk {
-			dailyStats = newMessageStats()
-			s.DailyStats[d] = dailyStats
-		}
-		monthlyStats, monthlyOk := s.MonthlyStats[mo]
-		if !monthlyOk {
-			monthlyStats = newMessageStats()
-			s.MonthlyStats[mo] = monthlyStats
-		}
-
-		userStats.TotalTextLength += len(m.Text)
-		userStats.NumMessages += 1
-		userStats.NumWords += len(words)
-		userStats.NumEmojis += len(emojis)
-		userStats.AvgCloutPerMsg += clout
-		userStats.AvgTonePerMsg += tone
-		userStats.AvgAnalyticPerMsg += analytic
-
-		dailyStats.TotalTextLength += len(m.Text)
-		dailyStats.NumMessages += 1
-		dailyStats.NumWords += len(words)
-		dailyStats.NumEmojis += len(emojis)
-		dailyStats.AvgCloutPerMsg += clout
-		dailyStats.AvgTonePerMsg += tone
-		dailyStats.AvgAnalyticPerMsg += analytic
-
-		monthlyStats.TotalTextLength += len(m.Text)
-		monthlyStats.NumMessages += 1
-		monthlyStats.NumWords += len(words)
-		monthlyStats.NumEmojis += len(emojis)
-		monthlyStats.AvgCloutPerMsg += clout
-		monthlyStats.AvgTonePerMsg += tone
-		monthlyStats.AvgAnalyticPerMsg += analytic
-
-		for _, w := range words {
-			if w == "" {
-				continue
-			}
-			l := float64(len(w))
-			s.AllStats.AvgWordLength += l
-			updateWordCountMap(w, &s.AllStats.WordCountMap)
-
-			userStats.AvgWordLength += l
-			updateWordCountMap(w, &userStats.WordCountMap)
 
-			dailyStats.AvgWordLength += l
-			updateWordCountMap(w, &dailyStats.WordCountMap)
+		pm := parseMessage(m)
 
-			monthlyStats.AvgWordLength += l
-			updateWordCountMap(w, &monthlyStats.WordCountMap)
-		}
-
-		for _, e := range emojis {
-			if e == "" {
-				continue
-			}
-			updateWordCountMap(e, &s.AllStats.EmojiCountMap)
-			updateWordCountMap(e, &userStats.EmojiCountMap)
-			updateWordCountMap(e, &dailyStats.EmojiCountMap)
-			updateWordCountMap(e, &monthlyStats.EmojiCountMap)
+		s.AllStats.addMessage(pm)
+		getMessageStats(s.UserStats, m.User).addMessage(pm)
+		if m.Channel != "" {
+			getMessageStats(s.ChannelStats, m.Channel).addMessage(pm)
 		}
+		getMessageStats(s.DailyStats, d).addMessage(pm)
+		getMessageStats(s.MonthlyStats, mo).addMessage(pm)
+		getMessageStats(s.LanguageStats, pm.language).addMessage(pm)
 	}
 
 	wordCategoriesCache := make(map[string][]string)
 	populateMsgCategoryCounts(s.AllStats, &wordCategoriesCache)
 	setStatAverages(s.AllStats)
-	for _, ms := range s.UserStats {
-		if ms.NumWords == 0 {
-			continue
-		}
-		populateMsgCategoryCounts(ms, &wordCategoriesCache)
-		setStatAverages(ms)
-	}
-	for _, ms := range s.DailyStats {
-		if ms.NumWords == 0 {
-			continue
+	s.AllStats.Links.setLinkShare(s.AllStats.Links.NumLinks)
+	for _, group := range []map[string]*MessageStats{s.UserStats, s.ChannelStats, s.DailyStats, s.MonthlyStats, s.LanguageStats} {
+		for _, ms := range group {
+			ms.Links.setLinkShare(s.AllStats.Links.NumLinks)
+			populateMsgCategoryCounts(ms, &wordCategoriesCache)
+			setStatAverages(ms)
 		}
-		populateMsgCategoryCounts(ms, &wordCategoriesCache)
-		setStatAverages(ms)
-	}
-	for _, ms := range s.MonthlyStats {
-		if ms.NumWords == 0 {
-			continue
-		}
-		populateMsgCategoryCounts(ms, &wordCategoriesCache)
-		setStatAverages(ms)
 	}
 	return
 }
 
-func ExportMessageAnalysis(messages []Message) {
-	s := AnalyzeMessages(messages)
-	ExportSlackMessageStats(s)
-}
-
-func printStats(ws *WordStats) {
-	fmt.Println("Total text length: " + strconv.Itoa(ws.TotalTextLength))
-	fmt.Println("Total words: " + strconv.Itoa(ws.TotalWords))
-	fmt.Println("Total messages: " + strconv.Itoa(ws.TotalMessages))
-	fmt.Println("Avg word length: " + floatStr(ws.AvgWordLength, 4))
-	fmt.Println("Avg words per message: " + floatStr(ws.AvgWordsPerMsg, 4))
-	fmt.Println("Avg message clout: " + floatStr(ws.AvgCloutPerMsg, 4))
-	fmt.Println("Avg message tone: " + floatStr(ws.AvgTonePerMsg, 4))
-	fmt.Println("Avg message analytic: " + floatStr(ws.AvgAnalyticPerMsg, 4))
+// ExportMessageAnalysis analyzes messages and writes the stats as JSON
+// to a new file in the dashboard folder, returning any export error
+func ExportMessageAnalysis(messages []Message) error {
+	return ExportSlackMessageStats(AnalyzeMessages(messages))
 }
 
 // inList determines whether a word
@@ -503,7 +462,15 @@ func newWordStats() *WordStats {
 		AvgTonePerMsg:     0,
 		AvgAnalyticPerMsg: 0,
 		WordCountMap:      make(map[string]int),
+		BigramCountMap:    make(map[string]int),
+		TrigramCountMap:   make(map[string]int),
 		CategoryCounts:    make(map[string]int),
+		EmotionCounts:     newEmotionCounts(),
+		Code:              newCodeStats(),
+		Links:             newLinkStats(),
+		scoredWordCounts:  make(map[string]int),
+		stopwordCounts:    make(map[string]int),
+		emojiCounts:       make(map[string]int),
 	}
 }
 
@@ -519,8 +486,15 @@ func newMessageStats() *MessageStats {
 		AvgTonePerMsg:     0,
 		AvgAnalyticPerMsg: 0,
 		WordCountMap:      make(map[string]int),
+		BigramCountMap:    make(map[string]int),
+		TrigramCountMap:   make(map[string]int),
 		EmojiCountMap:     make(map[string]int),
 		CategoryCounts:    make(map[string]int),
+		EmotionCounts:     newEmotionCounts(),
+		Code:              newCodeStats(),
+		Links:             newLinkStats(),
+		scoredWordCounts:  make(map[string]int),
+		stopwordCounts:    make(map[string]int),
 	}
 }
 
@@ -534,7 +508,7 @@ func updateWordCountMap(w string, wcm *map[string]int) {
 }
 
 func populateCategoryCounts(ws *WordStats, wordCategoriesCache *map[string][]string) {
-	for word, count := range (*ws).WordCountMap {
+	for word, count := range (*ws).scoredWordCounts {
 		categories, hit := (*wordCategoriesCache)[word]
 		if !hit {
 			categories = GetCategories(word)
@@ -548,11 +522,20 @@ func populateCategoryCounts(ws *WordStats, wordCategoriesCache *map[string][]str
 				(*ws).CategoryCounts[cat] = count
 			}
 		}
+		for _, e := range GetWordEmotions(categories) {
+			(*ws).EmotionCounts[e] += count
+		}
 	}
+	for emoji, count := range (*ws).emojiCounts {
+		if emotion, ok := EmojiEmotions[strings.Trim(emoji, ":")]; ok {
+			(*ws).EmotionCounts[emotion] += count
+		}
+	}
+	(*ws).EmotionShares = GetEmotionShares((*ws).EmotionCounts)
 }
 
 func populateMsgCategoryCounts(ms *MessageStats, wordCategoriesCache *map[string][]string) {
-	for word, count := range (*ms).WordCountMap {
+	for word, count := range (*ms).scoredWordCounts {
 		categories, hit := (*wordCategoriesCache)[word]
 		if !hit {
 			categories = GetCategories(word)
@@ -566,27 +549,193 @@ func populateMsgCategoryCounts(ms *MessageStats, wordCategoriesCache *map[string
 				(*ms).CategoryCounts[cat] = count
 			}
 		}
+		for _, e := range GetWordEmotions(categories) {
+			(*ms).EmotionCounts[e] += count
+		}
 	}
+	for emoji, count := range (*ms).EmojiCountMap {
+		if emotion, ok := EmojiEmotions[strings.Trim(emoji, ":")]; ok {
+			(*ms).EmotionCounts[emotion] += count
+		}
+	}
+	(*ms).EmotionShares = GetEmotionShares((*ms).EmotionCounts)
 }
 
 func setAverages(ws *WordStats) {
 	totalWords := float64(ws.TotalWords)
 	totalMessages := float64(ws.TotalMessages)
-	ws.AvgWordLength /= totalWords
-	ws.AvgWordsPerMsg = totalWords / totalMessages
-	ws.AvgCloutPerMsg /= totalMessages
-	ws.AvgTonePerMsg /= totalMessages
-	ws.AvgAnalyticPerMsg /= totalMessages
+	ws.AvgWordLength = ratio(ws.AvgWordLength, totalWords)
+	ws.AvgWordsPerMsg = ratio(totalWords, totalMessages)
+	ws.Code.CodeShare = ratio(float64(ws.Code.NumCodeMessages), totalMessages)
+	scoredMessages := float64(ws.TotalScoredMsgs)
+	ws.AvgCloutPerMsg = ratio(ws.AvgCloutPerMsg, scoredMessages)
+	ws.AvgTonePerMsg = ratio(ws.AvgTonePerMsg, scoredMessages)
+	ws.AvgAnalyticPerMsg = ratio(ws.AvgAnalyticPerMsg, scoredMessages)
+	ws.Scores = GetSummaryScores(ws.scoredWordCounts, ws.emojiCounts)
+	ws.Readability.setScores()
 }
 
 func setStatAverages(ms *MessageStats) {
 	numWords := float64(ms.NumWords)
 	numEmojis := float64(ms.NumEmojis)
 	numMsg := float64(ms.NumMessages)
-	ms.AvgWordLength /= numWords
-	ms.AvgWordsPerMsg = numWords / numMsg
-	ms.AvgEmojisPerMsg = numEmojis / numMsg
-	ms.AvgCloutPerMsg /= numMsg
-	ms.AvgTonePerMsg /= numMsg
-	ms.AvgAnalyticPerMsg /= numMsg
+	ms.AvgWordLength = ratio(ms.AvgWordLength, numWords)
+	ms.AvgWordsPerMsg = ratio(numWords, numMsg)
+	ms.AvgEmojisPerMsg = ratio(numEmojis, numMsg)
+	ms.Code.CodeShare = ratio(float64(ms.Code.NumCodeMessages), numMsg)
+	numScored := float64(ms.NumScoredMessages)
+	ms.AvgCloutPerMsg = ratio(ms.AvgCloutPerMsg, numScored)
+	ms.AvgTonePerMsg = ratio(ms.AvgTonePerMsg, numScored)
+	ms.AvgAnalyticPerMsg = ratio(ms.AvgAnalyticPerMsg, numScored)
+	ms.Scores = GetSummaryScores(ms.scoredWordCounts, ms.EmojiCountMap)
+	ms.Readability.setScores()
+}
+
+// ratio divides a by b, or returns 0 if b is 0 so that
+// empty stats never hold NaN (which JSON cannot encode)
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// parsedMessage holds the words and scores of a message
+// shared by every stats bucket it counts toward
+type parsedMessage struct {
+	text     string
+	prose    string
+	snippets []CodeSnippet
+	links    []Link
+	language string
+	scored   bool
+	words    []string
+	emojis   []string
+	bigrams  []string
+	trigrams []string
+	clout    float64
+	tone     float64
+	analytic float64
+}
+
+// parseMessage extracts the code and links of a message, tokenizes the
+// rest as TokenizeMessage does, detects its language and, if it is a
+// scored language, calculates its lexicon scores
+func parseMessage(m Message) (pm parsedMessage) {
+	pm = parsedMessage{text: m.Text}
+	pm.prose, pm.snippets = ExtractCode(m.Text)
+	pm.prose, pm.links = ExtractLinks(pm.prose)
+	words, emojis := tokenizeProse(pm.prose)
+	pm.language = tokensLanguage(words)
+	pm.words = words
+	pm.emojis = emojis
+	pm.bigrams = GetNgrams(words, 2)
+	pm.trigrams = GetNgrams(words, 3)
+	pm.scored = isScoredLanguage(pm.language)
+	if pm.scored {
+		pm.clout = float64(GetClout(words))
+		pm.tone = float64(GetTone(words) + GetEmojiTone(emojis))
+		pm.analytic = float64(GetAnalytic(words))
+	}
+	return
+}
+
+// tokensLanguage detects the language of a message
+// from its words as returned by TokenizeMessage
+func tokensLanguage(words []string) string {
+	return DetectLanguage(strings.Join(words, " "))
+}
+
+// getMessageStats returns the stats of key in
+// group, adding new stats if there are none yet
+func getMessageStats(group map[string]*MessageStats, key string) *MessageStats {
+	ms, ok := group[key]
+	if !ok {
+		ms = newMessageStats()
+		group[key] = ms
+	}
+	return ms
+}
+
+func (ws *WordStats) addMessage(pm parsedMessage) {
+	ws.TotalTextLength += len(pm.text)
+	ws.TotalMessages += 1
+	if pm.scored {
+		ws.TotalScoredMsgs += 1
+		ws.AvgCloutPerMsg += pm.clout
+		ws.AvgTonePerMsg += pm.tone
+		ws.AvgAnalyticPerMsg += pm.analytic
+	}
+	ws.Readability.addSentences(pm.prose)
+	ws.Code.addSnippets(pm.snippets)
+	ws.Links.addLinks(pm.links)
+	for _, w := range pm.words {
+		if w == "" {
+			continue
+		}
+		ws.TotalWords += 1
+		ws.AvgWordLength += float64(len(w))
+		updateWordCountMap(w, &ws.WordCountMap)
+		if pm.scored {
+			updateWordCountMap(w, &ws.scoredWordCounts)
+		}
+		if IsStopwordIn(w, pm.language) {
+			updateWordCountMap(w, &ws.stopwordCounts)
+		}
+		ws.Readability.addWord(w)
+	}
+	for _, b := range pm.bigrams {
+		updateWordCountMap(b, &ws.BigramCountMap)
+	}
+	for _, t := range pm.trigrams {
+		updateWordCountMap(t, &ws.TrigramCountMap)
+	}
+	for _, e := range pm.emojis {
+		if e == "" {
+			continue
+		}
+		updateWordCountMap(e, &ws.emojiCounts)
+	}
+}
+
+func (ms *MessageStats) addMessage(pm parsedMessage) {
+	ms.TotalTextLength += len(pm.text)
+	ms.NumMessages += 1
+	ms.NumWords += len(pm.words)
+	ms.NumEmojis += len(pm.emojis)
+	if pm.scored {
+		ms.NumScoredMessages += 1
+		ms.AvgCloutPerMsg += pm.clout
+		ms.AvgTonePerMsg += pm.tone
+		ms.AvgAnalyticPerMsg += pm.analytic
+	}
+	ms.Readability.addSentences(pm.prose)
+	ms.Code.addSnippets(pm.snippets)
+	ms.Links.addLinks(pm.links)
+	for _, w := range pm.words {
+		if w == "" {
+			continue
+		}
+		ms.AvgWordLength += float64(len(w))
+		updateWordCountMap(w, &ms.WordCountMap)
+		if pm.scored {
+			updateWordCountMap(w, &ms.scoredWordCounts)
+		}
+		if IsStopwordIn(w, pm.language) {
+			updateWordCountMap(w, &ms.stopwordCounts)
+		}
+		ms.Readability.addWord(w)
+	}
+	for _, b := range pm.bigrams {
+		updateWordCountMap(b, &ms.BigramCountMap)
+	}
+	for _, t := range pm.trigrams {
+		updateWordCountMap(t, &ms.TrigramCountMap)
+	}
+	for _, e := range pm.emojis {
+		if e == "" {
+			continue
+		}
+		updateWordCountMap(e, &ms.EmojiCountMap)
+	}
 }
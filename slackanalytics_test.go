@@ -0,0 +1,101 @@
+package slackanalytics
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// pipelineMessages mix words with punctuation, emojis, code and links
+var pipelineMessages = []Message{
+	{User: "U1", Text: "Great work, team! :tada: see https://example.com/docs", TimeStamp: "1600000000.000100"},
+	{User: "U2", Text: "Thanks 👍 the `make build` step is fixed.", TimeStamp: "1600000100.000100"},
+	{User: "U1", Text: "We are happy: no more *flaky* tests :smile:", TimeStamp: "1600000200.000100"},
+	{User: "U2", Text: "https://example.com/only-a-link", TimeStamp: "1600000300.000100"},
+}
+
+func TestStatsPipelinesAgree(t *testing.T) {
+	users := []*User{{Id: "U1"}, {Id: "U2"}}
+	channels := []*Channel{{Id: "C1", Name: "general", Messages: pipelineMessages}}
+	messages := append([]Message{}, pipelineMessages...)
+	for i := range messages {
+		messages[i].Channel = "general"
+	}
+	ss := GetSlackStats(users, channels)
+	s := AnalyzeMessages(messages)
+	if ss.AllStats.TotalWords != s.AllStats.NumWords {
+		t.Errorf("GetSlackStats counts %d words, AnalyzeMessages %d", ss.AllStats.TotalWords, s.AllStats.NumWords)
+	}
+	if ss.AllStats.Scores != s.AllStats.Scores {
+		t.Errorf("GetSlackStats scores %+v, AnalyzeMessages %+v", ss.AllStats.Scores, s.AllStats.Scores)
+	}
+	for _, id := range []string{"U1", "U2"} {
+		if ss.UserStats[id].Scores != s.UserStats[id].Scores {
+			t.Errorf("user %s: GetSlackStats scores %+v, AnalyzeMessages %+v", id, ss.UserStats[id].Scores, s.UserStats[id].Scores)
+		}
+	}
+	for e, c := range s.AllStats.EmotionCounts {
+		if ss.AllStats.EmotionCounts[e] != c {
+			t.Errorf("emotion %s: GetSlackStats counts %d, AnalyzeMessages %d", e, ss.AllStats.EmotionCounts[e], c)
+		}
+	}
+	for w, c := range s.AllStats.WordCountMap {
+		if ss.AllStats.WordCountMap[w] != c {
+			t.Errorf("word %q: GetSlackStats counts %d, AnalyzeMessages %d", w, ss.AllStats.WordCountMap[w], c)
+		}
+	}
+}
+
+func TestGetMessageEmotionsConcurrent(t *testing.T) {
+	m := Message{Text: "so happy and grateful for the help :tada:"}
+	want := GetMessageEmotions(m)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := GetMessageEmotions(m); !reflect.DeepEqual(got, want) {
+				t.Errorf("GetMessageEmotions = %v, want %v", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGetAndPrintStatsZeroOptions(t *testing.T) {
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	channels := []*Channel{{Id: "C1", Name: "general", Messages: pipelineMessages}}
+	GetAndPrintStatsWithOptions(nil, channels, TerminalOptions{})
+}
+
+func TestResultTypesHaveJSONTags(t *testing.T) {
+	for _, v := range []interface{}{PhraseCount{}, Trend{}, Trends{}, TrendReport{}, Question{}, QuestionReport{}, WordScore{}, AlertMatch{}} {
+		rt := reflect.TypeOf(v)
+		for i := 0; i < rt.NumField(); i++ {
+			if f := rt.Field(i); f.IsExported() && f.Tag.Get("json") == "" {
+				t.Errorf("%s.%s has no json tag", rt.Name(), f.Name)
+			}
+		}
+	}
+}
+
+func TestGetSlackStatsChannelStatsById(t *testing.T) {
+	channels := []*Channel{{Id: "C1", Name: "general", Messages: []Message{
+		{User: "U1", Text: "hello team", TimeStamp: "1600000000.000100"},
+	}}}
+	ss := GetSlackStats(nil, channels)
+	if cs, ok := ss.ChannelStats["C1"]; !ok || cs.TotalMessages != 1 {
+		t.Errorf("channel stats of C1 = %+v, want 1 message", cs)
+	}
+	if _, ok := ss.ChannelStats["general"]; ok {
+		t.Error("channel stats are keyed by channel name")
+	}
+}
@@ -0,0 +1,211 @@
+package slackanalytics
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+)
+
+const (
+	// SQLiteDriver is the database/sql driver name ExportSQLite opens;
+	// register it with the pure-Go driver (no CGO) by importing
+	// _ "modernc.org/sqlite", as cmd/analyze does
+	SQLiteDriver = "sqlite"
+)
+
+// SQLiteSchema creates the tables written by ExportSQLite. Messages
+// reference channels and users by id; stats hold one row per kind
+// ("all", "user", "channel", "day", "month" or "language") and key
+// (user id, channel id, day, month or language) with the columns
+// of StatsColumns, counts as integers and other metrics as reals
+var SQLiteSchema = `
+CREATE TABLE users (
+	id           TEXT PRIMARY KEY,
+	name         TEXT NOT NULL,
+	real_name    TEXT NOT NULL,
+	display_name TEXT NOT NULL,
+	title        TEXT NOT NULL,
+	time_zone    TEXT NOT NULL,
+	is_admin     INTEGER NOT NULL,
+	is_bot       INTEGER NOT NULL,
+	deleted      INTEGER NOT NULL
+);
+
+CREATE TABLE channels (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL UNIQUE,
+	created     TEXT NOT NULL,
+	creator     TEXT NOT NULL,
+	is_archived INTEGER NOT NULL,
+	is_general  INTEGER NOT NULL,
+	topic       TEXT NOT NULL,
+	purpose     TEXT NOT NULL
+);
+
+CREATE TABLE memberships (
+	channel_id TEXT NOT NULL REFERENCES channels(id),
+	user_id    TEXT NOT NULL REFERENCES users(id),
+	PRIMARY KEY (channel_id, user_id)
+);
+CREATE INDEX memberships_user ON memberships(user_id);
+
+-- time is the message time in unix seconds, day and month
+-- are local dates formatted as YYYY-MM-DD and YYYY-MM
+CREATE TABLE messages (
+	id         INTEGER PRIMARY KEY,
+	channel_id TEXT NOT NULL REFERENCES channels(id),
+	user_id    TEXT NOT NULL,
+	ts         TEXT NOT NULL,
+	thread_ts  TEXT NOT NULL,
+	time       INTEGER NOT NULL,
+	day        TEXT NOT NULL,
+	month      TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	subtype    TEXT NOT NULL,
+	text       TEXT NOT NULL,
+	language   TEXT NOT NULL
+);
+CREATE INDEX messages_channel_time ON messages(channel_id, time);
+CREATE INDEX messages_user_time ON messages(user_id, time);
+CREATE INDEX messages_thread ON messages(channel_id, thread_ts);
+CREATE INDEX messages_day ON messages(day);
+
+CREATE TABLE reactions (
+	message_id INTEGER NOT NULL REFERENCES messages(id),
+	name       TEXT NOT NULL,
+	user_id    TEXT NOT NULL
+);
+CREATE INDEX reactions_message ON reactions(message_id);
+CREATE INDEX reactions_name ON reactions(name);
+CREATE INDEX reactions_user ON reactions(user_id);
+
+CREATE TABLE stats (
+	kind TEXT NOT NULL,
+	key  TEXT NOT NULL,
+	` + sqliteStatsColumns() + `,
+	PRIMARY KEY (kind, key)
+);
+`
+
+// ExportSQLite writes users, channels, memberships, messages, reactions
+// and the computed stats to a new SQLite database at filePath (see
+// SQLiteSchema), replacing any existing file
+func ExportSQLite(filePath string, users []*User, channels []*Channel, s SlackMessageStats) (err error) {
+	err = os.Remove(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	db, err := sql.Open(SQLiteDriver, filePath)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	err = writeSQLite(tx, users, channels, s)
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+	err = tx.Commit()
+	return
+}
+
+// writeSQLite creates the schema and inserts all rows in a transaction
+func writeSQLite(tx *sql.Tx, users []*User, channels []*Channel, s SlackMessageStats) (err error) {
+	if _, err = tx.Exec(SQLiteSchema); err != nil {
+		return
+	}
+	insertUser, err := tx.Prepare("INSERT INTO users VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	for _, u := range users {
+		_, err = insertUser.Exec(u.Id, u.Name, u.Profile.RealName, GetUserName(u), u.Profile.Title, u.TimeZone, u.IsAdmin, u.IsBot, u.Deleted)
+		if err != nil {
+			return
+		}
+	}
+	insertChannel, err := tx.Prepare("INSERT INTO channels VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	insertMember, err := tx.Prepare("INSERT OR IGNORE INTO memberships VALUES (?, ?)")
+	if err != nil {
+		return
+	}
+	insertMessage, err := tx.Prepare("INSERT INTO messages VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	insertReaction, err := tx.Prepare("INSERT INTO reactions VALUES (?, ?, ?)")
+	if err != nil {
+		return
+	}
+	id := 0
+	for _, c := range channels {
+		_, err = insertChannel.Exec(c.Id, c.Name, c.Created, c.Creator, c.IsArchived, c.IsGeneral, c.Topic.Value, c.Purpose.Value)
+		if err != nil {
+			return
+		}
+		for _, userId := range c.Members {
+			if _, err = insertMember.Exec(c.Id, userId); err != nil {
+				return
+			}
+		}
+		for _, m := range c.Messages {
+			id += 1
+			t := messageTime(m)
+			language := LanguageUndetermined
+			if m.Text != "" {
+				language = DetectLanguage(proseText(m.Text))
+			}
+			_, err = insertMessage.Exec(id, c.Id, m.User, m.TimeStamp, m.ThreadTimeStamp, t.Unix(),
+				t.Format("2006-01-02"), t.Format("2006-01"), m.Type, m.SubType, m.Text, language)
+			if err != nil {
+				return
+			}
+			for _, r := range m.Reactions {
+				for _, userId := range r.Users {
+					if _, err = insertReaction.Exec(id, NormalizeEmoji(":"+r.Name+":"), userId); err != nil {
+						return
+					}
+				}
+			}
+		}
+	}
+	insertStats, err := tx.Prepare("INSERT INTO stats VALUES (?, ?" + strings.Repeat(", ?", len(StatsColumns)) + ")")
+	if err != nil {
+		return
+	}
+	channelIds := make(map[string]string)
+	for _, c := range channels {
+		channelIds[c.Name] = c.Id
+	}
+	for _, r := range GetStatsRecords(s) {
+		key := r.Key
+		if id, ok := channelIds[key]; ok && r.Kind == "channel" {
+			key = id
+		}
+		values := append([]interface{}{r.Kind, key}, statsValues(r.Stats)...)
+		if _, err = insertStats.Exec(values...); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// sqliteStatsColumns declares the columns of StatsColumns,
+// typed after the metrics returned by statsValues
+func sqliteStatsColumns() string {
+	columns := make([]string, len(StatsColumns))
+	for i, v := range statsValues(newMessageStats()) {
+		columns[i] = StatsColumns[i] + " REAL NOT NULL"
+		if _, ok := v.(int); ok {
+			columns[i] = StatsColumns[i] + " INTEGER NOT NULL"
+		}
+	}
+	return strings.Join(columns, ",\n\t")
+}
@@ -0,0 +1,135 @@
+"use strict";
+
+const metricCards = [
+  ["num_messages", "messages"],
+  ["num_words", "words"],
+  ["num_emojis", "emojis"],
+  ["clout", "clout"],
+  ["tone", "tone"],
+  ["analytic", "analytic"],
+  ["flesch_reading_ease", "reading ease"],
+];
+
+async function get(path) {
+  const res = await fetch(path);
+  const body = await res.json();
+  if (!res.ok) {
+    throw new Error(body.error || res.statusText);
+  }
+  return body;
+}
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    node.setAttribute(k, v);
+  }
+  for (const c of children) {
+    node.append(c instanceof Node ? c : document.createTextNode(String(c)));
+  }
+  return node;
+}
+
+function fmt(n) {
+  return Number.isInteger(n) ? String(n) : n.toFixed(2);
+}
+
+function table(id, columns, rows) {
+  const t = document.getElementById(id);
+  t.replaceChildren(el("tr", {}, ...columns.map((c) => el("th", {}, c))));
+  for (const row of rows) {
+    t.append(el("tr", {}, ...row.map((v) => el("td", {}, typeof v === "number" ? fmt(v) : v))));
+  }
+}
+
+function barChart(id, points) {
+  const width = 720;
+  const height = 160;
+  const plot = height - 20;
+  const svgNS = "http://www.w3.org/2000/svg";
+  const svg = document.createElementNS(svgNS, "svg");
+  svg.setAttribute("viewBox", `0 0 ${width} ${height}`);
+  svg.setAttribute("preserveAspectRatio", "none");
+  const max = Math.max(0, ...points.map((p) => p.value));
+  const step = width / Math.max(points.length, 1);
+  const gap = step < 3 ? 0 : step * 0.15;
+  points.forEach((p, i) => {
+    const h = max > 0 ? (plot * Math.max(p.value, 0)) / max : 0;
+    const rect = document.createElementNS(svgNS, "rect");
+    rect.setAttribute("x", i * step + gap / 2);
+    rect.setAttribute("y", plot - h);
+    rect.setAttribute("width", step - gap);
+    rect.setAttribute("height", h);
+    const title = document.createElementNS(svgNS, "title");
+    title.textContent = `${p.label}: ${fmt(p.value)}`;
+    rect.append(title);
+    svg.append(rect);
+  });
+  if (points.length > 0) {
+    const labels = [[0, "start", points[0].label], [width, "end", points[points.length - 1].label]];
+    for (const [x, anchor, text] of labels) {
+      const t = document.createElementNS(svgNS, "text");
+      t.setAttribute("x", x);
+      t.setAttribute("y", height);
+      t.setAttribute("text-anchor", anchor);
+      t.textContent = text;
+      svg.append(t);
+    }
+  }
+  document.getElementById(id).replaceChildren(svg);
+}
+
+async function loadCharts(period) {
+  barChart("activity", await get(`/api/graphs/activity?period=${period}`));
+  barChart("tone", await get(`/api/graphs/tone?period=${period}`));
+}
+
+async function load() {
+  const overview = await get("/api/overview");
+  document.getElementById("title").textContent = overview.title;
+  if (overview.first_day) {
+    document.getElementById("range").textContent = `${overview.first_day} to ${overview.last_day}`;
+  }
+  const cards = metricCards.map(([key, label]) =>
+    el("div", { class: "card" }, el("div", { class: "value" }, fmt(overview.metrics[key])), el("div", { class: "label" }, label)));
+  cards.push(el("div", { class: "card" }, el("div", { class: "value" }, overview.num_users), el("div", { class: "label" }, "active users")));
+  cards.push(el("div", { class: "card" }, el("div", { class: "value" }, overview.num_channels), el("div", { class: "label" }, "channels")));
+  document.getElementById("overview").replaceChildren(...cards);
+
+  const groupRow = (s) => [s.name || s.key, s.metrics.num_messages, s.metrics.num_words, s.metrics.tone, s.metrics.clout];
+  const groupColumns = ["Name", "Messages", "Words", "Tone", "Clout"];
+  table("users", groupColumns, (await get("/api/users")).map(groupRow));
+  table("channels", groupColumns, (await get("/api/channels")).map((s) => groupRow({ ...s, name: "#" + s.key })));
+  table("words", ["Word", "Count"], (await get("/api/words")).map((w) => [w.word, w.count]));
+  table("emojis", ["Emoji", "Count"], overview.top_emojis.map((e) => [e.word, e.count]));
+  table("categories", ["Category", "Count"], overview.categories.map((c) => [c.word, c.count]));
+  await loadCharts("day");
+}
+
+document.querySelectorAll(".toggle button").forEach((b) => {
+  b.addEventListener("click", () => {
+    document.querySelectorAll(".toggle button").forEach((o) => o.classList.toggle("active", o === b));
+    loadCharts(b.dataset.period);
+  });
+});
+
+document.getElementById("search").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const q = new FormData(e.target).get("q");
+  const results = document.getElementById("results");
+  try {
+    const docs = await get(`/api/search?q=${encodeURIComponent(q)}`);
+    results.replaceChildren(...docs.map((d) => el("div", { class: "result" },
+      el("div", { class: "who" }, `#${d.channel} · ${d.user_name || d.user} · ${new Date(parseFloat(d.ts) * 1000).toLocaleString()}`),
+      el("div", { class: "text" }, d.text))));
+    if (docs.length === 0) {
+      results.replaceChildren(el("p", {}, "No results."));
+    }
+  } catch (err) {
+    results.replaceChildren(el("p", { class: "error" }, err.message));
+  }
+});
+
+load().catch((err) => {
+  document.getElementById("overview").replaceChildren(el("p", { class: "error" }, err.message));
+});
@@ -215,6 +215,13 @@ var (
 	}
 )
 
+func init() {
+	SortCategories()
+}
+
+// SortCategories sorts the word lists of Categories, which GetCategories
+// searches; they are sorted on start-up, so only call it again after
+// changing Categories
 func SortCategories() {
 	for _, catWords := range Categories {
 		sort.Strings(catWords)
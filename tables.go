@@ -0,0 +1,241 @@
+package slackanalytics
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+const (
+	// TableCSV writes tables as comma separated values
+	TableCSV = "csv"
+	// TableTSV writes tables as tab separated values
+	TableTSV = "tsv"
+)
+
+// TableFormats lists the supported table formats
+var TableFormats = []string{TableCSV, TableTSV}
+
+// Table is a flat table of stats ready for a spreadsheet;
+// Name is used as the file name when exporting tables
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+// WordStatsColumns are the names of the scalar metrics
+// written for word stats (see GetSlackStatsTables), in order
+var WordStatsColumns = []string{
+	"total_messages", "total_scored_msgs", "total_words", "total_text_length",
+	"avg_word_length", "avg_words_per_msg",
+	"avg_clout_per_msg", "avg_tone_per_msg", "avg_analytic_per_msg",
+	"clout", "tone", "analytic", "clout_percent", "tone_percent", "analytic_percent",
+	"flesch_reading_ease", "flesch_kincaid_grade", "gunning_fog", "type_token_ratio", "mtld",
+	"num_code_messages", "code_share", "num_links", "link_share",
+}
+
+// countGroup is a group of count maps keyed by user, channel, day...
+type countGroup struct {
+	kind   string
+	counts map[string]map[string]int
+}
+
+// GetMessageStatsTables flattens message stats into tables: "users",
+// "channels", "days" and "months" hold one row of scalar metrics per key
+// (see StatsColumns), and "words", "emojis" and "categories" hold one
+// row per group, key and word (or emoji, or category) with its count
+func GetMessageStatsTables(s SlackMessageStats) (tables []Table) {
+	groups := []struct {
+		name  string
+		kind  string
+		stats map[string]*MessageStats
+	}{
+		{"users", "user", s.UserStats},
+		{"channels", "channel", s.ChannelStats},
+		{"days", "day", s.DailyStats},
+		{"months", "month", s.MonthlyStats},
+	}
+	all := map[string]*MessageStats{}
+	if s.AllStats != nil {
+		all[""] = s.AllStats
+	}
+	words := []countGroup{}
+	emojis := []countGroup{}
+	categories := []countGroup{}
+	addCounts := func(kind string, stats map[string]*MessageStats) {
+		w := countGroup{kind, make(map[string]map[string]int)}
+		e := countGroup{kind, make(map[string]map[string]int)}
+		c := countGroup{kind, make(map[string]map[string]int)}
+		for k, ms := range stats {
+			w.counts[k] = ms.WordCountMap
+			e.counts[k] = ms.EmojiCountMap
+			c.counts[k] = ms.CategoryCounts
+		}
+		words = append(words, w)
+		emojis = append(emojis, e)
+		categories = append(categories, c)
+	}
+	addCounts("all", all)
+	for _, g := range groups {
+		t := Table{Name: g.name, Columns: append([]string{g.kind}, StatsColumns...)}
+		for _, k := range sortedStatsKeys(g.stats) {
+			t.Rows = append(t.Rows, append([]string{k}, statsRow(g.stats[k])...))
+		}
+		tables = append(tables, t)
+		addCounts(g.kind, g.stats)
+	}
+	tables = append(tables,
+		countTable("words", "word", words),
+		countTable("emojis", "emoji", emojis),
+		countTable("categories", "category", categories),
+	)
+	return
+}
+
+// GetSlackStatsTables flattens word stats into tables: "users" and
+// "channels" hold one row of scalar metrics per user or channel id along
+// with its name (see WordStatsColumns), and "words" and "categories"
+// hold one row per group, id and word (or category) with its count
+func GetSlackStatsTables(ss SlackStats, users []*User, channels []*Channel) (tables []Table) {
+	names := make(map[string]string)
+	for _, u := range users {
+		names[u.Id] = GetUserName(u)
+	}
+	for _, c := range channels {
+		names[c.Id] = c.Name
+	}
+	groups := []struct {
+		name  string
+		kind  string
+		stats map[string]*WordStats
+	}{
+		{"users", "user", ss.UserStats},
+		{"channels", "channel", ss.ChannelStats},
+	}
+	words := []countGroup{}
+	categories := []countGroup{}
+	if ss.AllStats != nil {
+		words = append(words, countGroup{"all", map[string]map[string]int{"": ss.AllStats.WordCountMap}})
+		categories = append(categories, countGroup{"all", map[string]map[string]int{"": ss.AllStats.CategoryCounts}})
+	}
+	for _, g := range groups {
+		t := Table{Name: g.name, Columns: append([]string{g.kind, "name"}, WordStatsColumns...)}
+		w := countGroup{g.kind, make(map[string]map[string]int)}
+		c := countGroup{g.kind, make(map[string]map[string]int)}
+		keys := []string{}
+		for k, ws := range g.stats {
+			keys = append(keys, k)
+			w.counts[k] = ws.WordCountMap
+			c.counts[k] = ws.CategoryCounts
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			t.Rows = append(t.Rows, append([]string{k, names[k]}, wordStatsRow(g.stats[k])...))
+		}
+		tables = append(tables, t)
+		words = append(words, w)
+		categories = append(categories, c)
+	}
+	tables = append(tables,
+		countTable("words", "word", words),
+		countTable("categories", "category", categories),
+	)
+	return
+}
+
+// WriteTable writes a table with a header row to w,
+// separating values with commas or tabs
+func WriteTable(w io.Writer, t Table, format string) error {
+	cw := csv.NewWriter(w)
+	switch format {
+	case TableCSV:
+	case TableTSV:
+		cw.Comma = '\t'
+	default:
+		return errors.New("unknown table format " + format)
+	}
+	cw.Write(t.Columns)
+	cw.WriteAll(t.Rows)
+	return cw.Error()
+}
+
+// ExportTables writes each table to <dir>/<name>.<format>,
+// creating dir if it is missing
+func ExportTables(tables []Table, dir, format string) (err error) {
+	if !inList(format, TableFormats) {
+		return errors.New("unknown table format " + format)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return
+	}
+	for _, t := range tables {
+		var file *os.File
+		file, err = os.Create(filepath.Join(dir, t.Name+"."+format))
+		if err != nil {
+			return
+		}
+		err = WriteTable(file, t, format)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// countTable builds a long-format table of counts with the columns
+// group, key, column and count; rows are ordered by group, key and
+// count descending
+func countTable(name, column string, groups []countGroup) (t Table) {
+	t = Table{Name: name, Columns: []string{"group", "key", column, "count"}}
+	for _, g := range groups {
+		keys := make([]string, 0, len(g.counts))
+		for k := range g.counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, wc := range topCounts(g.counts[k], len(g.counts[k])) {
+				if wc.Count == 0 {
+					continue
+				}
+				t.Rows = append(t.Rows, []string{g.kind, k, wc.Word, strconv.Itoa(wc.Count)})
+			}
+		}
+	}
+	return
+}
+
+// wordStatsRow returns the scalar metrics of word stats in WordStatsColumns order
+func wordStatsRow(ws *WordStats) []string {
+	row := intCells(ws.TotalMessages, ws.TotalScoredMsgs, ws.TotalWords, ws.TotalTextLength)
+	row = append(row, floatCells(ws.AvgWordLength, ws.AvgWordsPerMsg,
+		ws.AvgCloutPerMsg, ws.AvgTonePerMsg, ws.AvgAnalyticPerMsg,
+		ws.Scores.Clout, ws.Scores.Tone, ws.Scores.Analytic,
+		ws.Scores.CloutPercent, ws.Scores.TonePercent, ws.Scores.AnalyticPercent,
+		ws.Readability.FleschReadingEase, ws.Readability.FleschKincaidGrade, ws.Readability.GunningFog,
+		ws.Readability.TypeTokenRatio, ws.Readability.MTLD)...)
+	row = append(row, intCells(ws.Code.NumCodeMessages)...)
+	row = append(row, floatCells(ws.Code.CodeShare)...)
+	row = append(row, intCells(ws.Links.NumLinks)...)
+	row = append(row, floatCells(ws.Links.LinkShare)...)
+	return row
+}
+
+// sortedStatsKeys returns the keys of a stats group in order
+func sortedStatsKeys(stats map[string]*MessageStats) (keys []string) {
+	keys = make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
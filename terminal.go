@@ -0,0 +1,322 @@
+package slackanalytics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	// sparkTicks are the levels of a sparkline on a terminal
+	// and plainSparkTicks those of the plain-text fallback
+	sparkTicks      = []rune("▁▂▃▄▅▆▇█")
+	plainSparkTicks = []rune("_.-=+*#@")
+
+	// MessageLengthBuckets are the upper bounds (in words) of the
+	// message length histogram; longer messages fall in a last bucket
+	MessageLengthBuckets = []int{1, 3, 7, 15, 31, 63}
+)
+
+// TerminalOptions configures how GetAndPrintStatsWithOptions prints
+// to Writer: Fancy draws bars and sparklines with Unicode blocks
+// instead of ASCII, Width is the widest a bar may be and TopWords
+// the number of top words printed; a nil Writer prints to stdout
+// and a Width below 1 uses the default width
+type TerminalOptions struct {
+	Writer   io.Writer
+	Fancy    bool
+	Width    int
+	TopWords int
+}
+
+// DefaultTerminalOptions prints to stdout, with Unicode charts
+// only if it is a terminal, bars of up to 40 characters and
+// the top 20 words
+func DefaultTerminalOptions() TerminalOptions {
+	return TerminalOptions{
+		Writer:   os.Stdout,
+		Fancy:    IsTerminal(os.Stdout),
+		Width:    40,
+		TopWords: 20,
+	}
+}
+
+// IsTerminal determines whether f is a terminal
+// rather than a file or pipe
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Sparkline draws values as a line of bars of eight heights, scaled
+// from zero to the largest value; fancy uses Unicode blocks
+func Sparkline(values []float64, fancy bool) string {
+	ticks := plainSparkTicks
+	if fancy {
+		ticks = sparkTicks
+	}
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 && v > 0 {
+			i = int(math.Ceil(v/max*float64(len(ticks)))) - 1
+		}
+		b.WriteRune(ticks[i])
+	}
+	return b.String()
+}
+
+// WriteBarChart writes a horizontal bar per label, scaled so that
+// the largest value is width characters wide, followed by the value
+func WriteBarChart(w io.Writer, labels []string, values []float64, width int, fancy bool) error {
+	bar := "#"
+	if fancy {
+		bar = "█"
+	}
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	for i, label := range labels {
+		n := 0
+		if max > 0 {
+			n = int(math.Round(values[i] / max * float64(width)))
+		}
+		if n == 0 && values[i] > 0 {
+			n = 1
+		}
+		fmt.Fprintf(tw, "  %s\t%s %s\n", label, strings.Repeat(bar, n), shortFloatStr(values[i]))
+	}
+	return tw.Flush()
+}
+
+// WriteStatsTable writes the scalar metrics of word stats as
+// an aligned table of names and right-aligned values
+func WriteStatsTable(w io.Writer, ws *WordStats) error {
+	rows := []struct {
+		name  string
+		value string
+	}{
+		{"Total text length", strconv.Itoa(ws.TotalTextLength)},
+		{"Total words", strconv.Itoa(ws.TotalWords)},
+		{"Total messages", strconv.Itoa(ws.TotalMessages)},
+		{"Avg word length", floatStr(ws.AvgWordLength, 4)},
+		{"Avg words per message", floatStr(ws.AvgWordsPerMsg, 4)},
+		{"Avg message clout", floatStr(ws.AvgCloutPerMsg, 4)},
+		{"Avg message tone", floatStr(ws.AvgTonePerMsg, 4)},
+		{"Avg message analytic", floatStr(ws.AvgAnalyticPerMsg, 4)},
+		{"Clout score (0-100)", floatStr(ws.Scores.Clout, 2)},
+		{"Tone score (0-100)", floatStr(ws.Scores.Tone, 2)},
+		{"Analytic score (0-100)", floatStr(ws.Scores.Analytic, 2)},
+		{"Flesch reading ease", floatStr(ws.Readability.FleschReadingEase, 2)},
+		{"Flesch-Kincaid grade", floatStr(ws.Readability.FleschKincaidGrade, 2)},
+		{"Gunning fog index", floatStr(ws.Readability.GunningFog, 2)},
+		{"Type-token ratio", floatStr(ws.Readability.TypeTokenRatio, 4)},
+		{"MTLD", floatStr(ws.Readability.MTLD, 2)},
+		{"Code share", floatStr(ws.Code.CodeShare, 4)},
+		{"Link share", floatStr(ws.Links.LinkShare, 4)},
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t\n", r.name, r.value)
+	}
+	return tw.Flush()
+}
+
+// WriteCountChart writes the counts of a map as a bar chart
+// sorted by count descending, keeping at most amount of them
+// if amount is positive
+func WriteCountChart(w io.Writer, counts map[string]int, amount, width int, fancy bool) error {
+	if amount <= 0 {
+		amount = len(counts)
+	}
+	labels, values := []string{}, []float64{}
+	for _, wc := range topCounts(counts, amount) {
+		labels = append(labels, wc.Word)
+		values = append(values, float64(wc.Count))
+	}
+	return WriteBarChart(w, labels, values, width, fancy)
+}
+
+// WriteActivityCharts writes the number of messages per day as a
+// sparkline, days without messages included, and per month as a
+// bar chart
+func WriteActivityCharts(w io.Writer, messages []Message, width int, fancy bool) error {
+	daily := make(map[string]int)
+	monthly := make(map[string]int)
+	for _, m := range messages {
+		if m.Text == "" {
+			continue
+		}
+		t := messageTime(m)
+		daily[t.Format("2006-01-02")] += 1
+		monthly[t.Format("2006-01")] += 1
+	}
+	if len(daily) == 0 {
+		return nil
+	}
+	days := make([]string, 0, len(daily))
+	for d := range daily {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	first, _ := time.Parse("2006-01-02", days[0])
+	last, _ := time.Parse("2006-01-02", days[len(days)-1])
+	values, max := []float64{}, 0
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		count := daily[d.Format("2006-01-02")]
+		values = append(values, float64(count))
+		if count > max {
+			max = count
+		}
+	}
+	fmt.Fprintln(w, "Daily messages ("+days[0]+" to "+days[len(days)-1]+", max "+strconv.Itoa(max)+"):")
+	for len(values) > 0 {
+		n := len(values)
+		if n > width*2 {
+			n = width * 2
+		}
+		fmt.Fprintln(w, "  "+Sparkline(values[:n], fancy))
+		values = values[n:]
+	}
+	months := make([]string, 0, len(monthly))
+	for mo := range monthly {
+		months = append(months, mo)
+	}
+	sort.Strings(months)
+	monthValues := make([]float64, len(months))
+	for i, mo := range months {
+		monthValues[i] = float64(monthly[mo])
+	}
+	fmt.Fprintln(w, "Monthly messages:")
+	return WriteBarChart(w, months, monthValues, width, fancy)
+}
+
+// WriteLengthHistogram writes a histogram of the number of words
+// per message, as counted by TokenizeMessage, over MessageLengthBuckets
+func WriteLengthHistogram(w io.Writer, messages []Message, width int, fancy bool) error {
+	counts := make([]float64, len(MessageLengthBuckets)+1)
+	for _, m := range messages {
+		words, _ := TokenizeMessage(m)
+		n := len(words)
+		if n == 0 {
+			continue
+		}
+		i := sort.SearchInts(MessageLengthBuckets, n)
+		counts[i] += 1
+	}
+	labels := make([]string, len(counts))
+	low := 1
+	for i, high := range MessageLengthBuckets {
+		labels[i] = strconv.Itoa(low) + "-" + strconv.Itoa(high)
+		if low == high {
+			labels[i] = strconv.Itoa(low)
+		}
+		low = high + 1
+	}
+	labels[len(labels)-1] = strconv.Itoa(low) + "+"
+	fmt.Fprintln(w, "Message length (words):")
+	return WriteBarChart(w, labels, counts, width, fancy)
+}
+
+// WriteCodeStats writes how much code was shared, a bar chart of
+// the languages of the snippets and the most pasted error signatures
+func WriteCodeStats(w io.Writer, cs CodeStats, width int, fancy bool) error {
+	fmt.Fprintln(w, "Code: "+strconv.Itoa(cs.NumCodeMessages)+" messages ("+floatStr(cs.CodeShare*100, 2)+"%), "+
+		strconv.Itoa(cs.NumSnippets)+" snippets of "+strconv.Itoa(cs.NumLines)+" lines, "+strconv.Itoa(cs.NumInline)+" inline")
+	if len(cs.LanguageCounts) > 0 {
+		fmt.Fprintln(w, "Snippet languages:")
+		if err := WriteCountChart(w, cs.LanguageCounts, 0, width, fancy); err != nil {
+			return err
+		}
+	}
+	signatures := GetTopErrorSignatures(cs, 5)
+	if len(signatures) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "Top error signatures:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, sig := range signatures {
+		fmt.Fprintf(tw, "  %d\t%s\n", sig.Count, sig.Word)
+	}
+	return tw.Flush()
+}
+
+// WriteLinkStats writes how many links were shared, bar charts of
+// their classes and most shared domains and the most shared links
+func WriteLinkStats(w io.Writer, ls LinkStats, width int, fancy bool) error {
+	fmt.Fprintln(w, "Links: "+strconv.Itoa(ls.NumLinks)+" in "+strconv.Itoa(ls.NumLinkMessages)+" messages")
+	fmt.Fprintln(w, "Link classes:")
+	if err := WriteCountChart(w, ls.ClassCounts, 0, width, fancy); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Top domains:")
+	if err := WriteCountChart(w, ls.DomainCounts, 10, width, fancy); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Top links:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, link := range GetTopLinks(ls, 5) {
+		fmt.Fprintf(tw, "  %d\t%s\n", link.Count, link.Word)
+	}
+	return tw.Flush()
+}
+
+// WriteTrendReport writes, for the workspace and then every channel,
+// the amount words rising and falling most sharply in the period of
+// a trend report with how their frequency changed
+func WriteTrendReport(w io.Writer, report TrendReport, amount int) error {
+	fmt.Fprintln(w, "Trends in "+report.Period+":")
+	channels := make([]string, 0, len(report.Channels))
+	for c := range report.Channels {
+		channels = append(channels, c)
+	}
+	sort.Strings(channels)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	writeTrends(tw, "workspace", report.Workspace, amount)
+	for _, c := range channels {
+		writeTrends(tw, "#"+c, report.Channels[c], amount)
+	}
+	return tw.Flush()
+}
+
+// writeTrends writes the rising and falling words of
+// trends on a line each, skipping empty lines
+func writeTrends(w io.Writer, label string, trends Trends, amount int) {
+	for _, dir := range []struct {
+		name   string
+		trends []Trend
+	}{{"rising", trends.Rising}, {"falling", trends.Falling}} {
+		if len(dir.trends) == 0 {
+			continue
+		}
+		words := []string{}
+		for i, t := range dir.trends {
+			if i >= amount {
+				break
+			}
+			change := "x" + shortFloatStr(t.Change)
+			if t.Direction == "new" {
+				change = "new"
+			} else if t.Count == 0 {
+				change = "gone"
+			}
+			words = append(words, t.Word+" ("+change+")")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", label, dir.name, strings.Join(words, ", "))
+	}
+}
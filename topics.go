@@ -0,0 +1,279 @@
+package slackanalytics
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// TopicDocMessage makes every message its own document
+	TopicDocMessage = "message"
+	// TopicDocChannelDay groups the messages of a channel per day into one document
+	TopicDocChannelDay = "channel-day"
+)
+
+// TopicOptions configures BuildTopicModel
+type TopicOptions struct {
+	NumTopics    int
+	Iterations   int
+	Alpha        float64
+	Beta         float64
+	TopWords     int
+	MinWordCount int
+	Document     string
+	Seed         int64
+}
+
+// Topic holds the most probable words of a topic, scored by p(word|topic)
+type Topic struct {
+	Id       int         `json:"id"`
+	TopWords []WordScore `json:"top_words"`
+}
+
+// MessageTopic is the dominant topic of a message along
+// with the share of the message's words assigned to it
+type MessageTopic struct {
+	Channel   string  `json:"channel"`
+	User      string  `json:"user"`
+	TimeStamp string  `json:"ts"`
+	Topic     int     `json:"topic"`
+	Weight    float64 `json:"weight"`
+}
+
+// TopicModel is the result of an LDA topic model over messages; shares
+// are indexed by topic id and sum to 1 for every channel and month
+type TopicModel struct {
+	Topics        []Topic              `json:"topics"`
+	ChannelShares map[string][]float64 `json:"channel_shares"`
+	MonthlyShares map[string][]float64 `json:"monthly_shares"`
+	MessageTopics []MessageTopic       `json:"message_topics"`
+}
+
+// topicToken is a word occurrence in the corpus along with
+// the document and message it came from and its current topic
+type topicToken struct {
+	word    int
+	doc     int
+	message int
+	topic   int
+}
+
+// DefaultTopicOptions returns options suitable for a medium sized
+// workspace: 10 topics over messages with 200 Gibbs sampling iterations
+func DefaultTopicOptions() TopicOptions {
+	return TopicOptions{
+		NumTopics:    10,
+		Iterations:   200,
+		Alpha:        0.1,
+		Beta:         0.01,
+		TopWords:     10,
+		MinWordCount: 3,
+		Document:     TopicDocMessage,
+		Seed:         1,
+	}
+}
+
+// BuildTopicModel fits a latent Dirichlet allocation topic model with
+// collapsed Gibbs sampling over the words of messages (see MessageToWords),
+// skipping common words and words seen fewer than MinWordCount times
+func BuildTopicModel(messages []Message, opt TopicOptions) (tm *TopicModel) {
+	tm = &TopicModel{
+		Topics:        []Topic{},
+		ChannelShares: make(map[string][]float64),
+		MonthlyShares: make(map[string][]float64),
+		MessageTopics: []MessageTopic{},
+	}
+	if opt.NumTopics <= 0 {
+		return
+	}
+	// tokenize and count the vocabulary
+	messageWords := make([][]string, len(messages))
+	wordCounts := make(map[string]int)
+	for i, m := range messages {
+		if m.Text == "" {
+			continue
+		}
+		msgWords, _ := TokenizeMessage(m)
+		lang := tokensLanguage(msgWords)
+		for _, w := range msgWords {
+			if w == "" || IsStopwordIn(w, lang) || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+				continue
+			}
+			messageWords[i] = append(messageWords[i], w)
+			wordCounts[w] += 1
+		}
+	}
+	vocab := make(map[string]int)
+	words := []string{}
+	docIds := make(map[string]int)
+	tokens := []topicToken{}
+	for i, m := range messages {
+		docKey := strconv.Itoa(i)
+		if opt.Document == TopicDocChannelDay {
+			docKey = m.Channel + " " + messageTime(m).Format("2006-01-02")
+		}
+		for _, w := range messageWords[i] {
+			if wordCounts[w] < opt.MinWordCount {
+				continue
+			}
+			id, ok := vocab[w]
+			if !ok {
+				id = len(words)
+				vocab[w] = id
+				words = append(words, w)
+			}
+			doc, ok := docIds[docKey]
+			if !ok {
+				doc = len(docIds)
+				docIds[docKey] = doc
+			}
+			tokens = append(tokens, topicToken{word: id, doc: doc, message: i})
+		}
+	}
+	if len(tokens) == 0 {
+		return
+	}
+
+	k := opt.NumTopics
+	v := float64(len(words))
+	docTopics := make([][]int, len(docIds))
+	for d := range docTopics {
+		docTopics[d] = make([]int, k)
+	}
+	wordTopics := make([][]int, len(words))
+	for w := range wordTopics {
+		wordTopics[w] = make([]int, k)
+	}
+	topicTotals := make([]int, k)
+	r := rand.New(rand.NewSource(opt.Seed))
+	for i := range tokens {
+		t := &tokens[i]
+		t.topic = r.Intn(k)
+		docTopics[t.doc][t.topic] += 1
+		wordTopics[t.word][t.topic] += 1
+		topicTotals[t.topic] += 1
+	}
+	probs := make([]float64, k)
+	for iter := 0; iter < opt.Iterations; iter++ {
+		for i := range tokens {
+			t := &tokens[i]
+			docTopics[t.doc][t.topic] -= 1
+			wordTopics[t.word][t.topic] -= 1
+			topicTotals[t.topic] -= 1
+			sum := 0.0
+			for z := 0; z < k; z++ {
+				p := (float64(docTopics[t.doc][z]) + opt.Alpha) *
+					(float64(wordTopics[t.word][z]) + opt.Beta) /
+					(float64(topicTotals[z]) + v*opt.Beta)
+				sum += p
+				probs[z] = sum
+			}
+			u := r.Float64() * sum
+			z := sort.SearchFloat64s(probs, u)
+			if z >= k {
+				z = k - 1
+			}
+			t.topic = z
+			docTopics[t.doc][z] += 1
+			wordTopics[t.word][z] += 1
+			topicTotals[z] += 1
+		}
+	}
+
+	// top words per topic
+	for z := 0; z < k; z++ {
+		scores := []WordScore{}
+		for w, counts := range wordTopics {
+			if counts[z] == 0 {
+				continue
+			}
+			phi := (float64(counts[z]) + opt.Beta) / (float64(topicTotals[z]) + v*opt.Beta)
+			scores = append(scores, WordScore{Word: words[w], Count: counts[z], Score: phi})
+		}
+		sort.SliceStable(scores, func(i, j int) bool {
+			return scores[i].Score > scores[j].Score
+		})
+		if len(scores) > opt.TopWords {
+			scores = scores[:opt.TopWords]
+		}
+		tm.Topics = append(tm.Topics, Topic{Id: z, TopWords: scores})
+	}
+
+	// per message, channel and month topic counts
+	messageTopicCounts := make(map[int][]int)
+	messageOrder := []int{}
+	channelCounts := make(map[string][]int)
+	monthlyCounts := make(map[string][]int)
+	for _, t := range tokens {
+		m := messages[t.message]
+		if _, ok := messageTopicCounts[t.message]; !ok {
+			messageTopicCounts[t.message] = make([]int, k)
+			messageOrder = append(messageOrder, t.message)
+		}
+		messageTopicCounts[t.message][t.topic] += 1
+		addTopicCount(channelCounts, m.Channel, t.topic, k)
+		addTopicCount(monthlyCounts, messageTime(m).Format("2006-01"), t.topic, k)
+	}
+	for _, i := range messageOrder {
+		counts := messageTopicCounts[i]
+		best := 0
+		total := 0
+		for z, c := range counts {
+			total += c
+			if c > counts[best] {
+				best = z
+			}
+		}
+		m := messages[i]
+		tm.MessageTopics = append(tm.MessageTopics, MessageTopic{
+			Channel:   m.Channel,
+			User:      m.User,
+			TimeStamp: m.TimeStamp,
+			Topic:     best,
+			Weight:    float64(counts[best]) / float64(total),
+		})
+	}
+	for channel, counts := range channelCounts {
+		tm.ChannelShares[channel] = topicShares(counts)
+	}
+	for month, counts := range monthlyCounts {
+		tm.MonthlyShares[month] = topicShares(counts)
+	}
+	return
+}
+
+// messageTime parses the timestamp of a message,
+// falling back to the current time
+func messageTime(m Message) time.Time {
+	t64, err := strconv.ParseFloat(m.TimeStamp, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(int64(t64), 0)
+}
+
+func addTopicCount(counts map[string][]int, key string, topic, numTopics int) {
+	if _, ok := counts[key]; !ok {
+		counts[key] = make([]int, numTopics)
+	}
+	counts[key][topic] += 1
+}
+
+// topicShares normalizes topic counts so they sum to 1
+func topicShares(counts []int) (shares []float64) {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	shares = make([]float64, len(counts))
+	for z, c := range counts {
+		if total > 0 {
+			shares[z] = float64(c) / float64(total)
+		}
+	}
+	return
+}
@@ -0,0 +1,23 @@
+package slackanalytics
+
+import "testing"
+
+func TestTopicWordsSkipEmojis(t *testing.T) {
+	messages := []Message{}
+	for i := 0; i < 6; i++ {
+		messages = append(messages,
+			Message{User: "U1", Channel: "eng", Text: "the deploy pipeline failed again :smile: :tada:", TimeStamp: "1600000000.000100"},
+			Message{User: "U2", Channel: "eng", Text: "lunch order pizza tonight :smile: 👍", TimeStamp: "1600000100.000100"},
+		)
+	}
+	opt := DefaultTopicOptions()
+	opt.NumTopics = 2
+	opt.MinWordCount = 1
+	for _, topic := range BuildTopicModel(messages, opt).Topics {
+		for _, w := range topic.TopWords {
+			if w.Word == "smile" || w.Word == "tada" || w.Word == "👍" {
+				t.Errorf("topic %d has the emoji word %q", topic.Id, w.Word)
+			}
+		}
+	}
+}
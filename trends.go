@@ -0,0 +1,235 @@
+package slackanalytics
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// TrendOptions configures trend detection; Period is a key of the
+// period stats (e.g. "2006-01"), defaulting to the latest period
+type TrendOptions struct {
+	Period        string
+	Baseline      int
+	MinCount      int
+	Amount        int
+	IncludeCommon bool
+}
+
+// Trend describes how often a word was used in a period compared to
+// the baseline periods before it; frequencies are per word of the period
+type Trend struct {
+	Word              string  `json:"word"`
+	Count             int     `json:"count"`
+	Frequency         float64 `json:"frequency"`
+	BaselineFrequency float64 `json:"baseline_frequency"`
+	Change            float64 `json:"change"`
+	ZScore            float64 `json:"z_score"`
+	Direction         string  `json:"direction"`
+}
+
+// Trends holds the words rising and falling most
+// sharply in a period, ranked by z-score
+type Trends struct {
+	Rising  []Trend `json:"rising"`
+	Falling []Trend `json:"falling"`
+}
+
+// TrendReport holds the trends of a period for
+// the whole workspace and for every channel
+type TrendReport struct {
+	Period    string            `json:"period"`
+	Workspace Trends            `json:"workspace"`
+	Channels  map[string]Trends `json:"channels"`
+}
+
+// DefaultTrendOptions compares the latest period against
+// the three before it and keeps the top 20 words each way
+func DefaultTrendOptions() TrendOptions {
+	return TrendOptions{
+		Baseline: 3,
+		MinCount: 3,
+		Amount:   20,
+	}
+}
+
+// GetTrendReport splits messages into monthly stats for the workspace
+// and for each channel and returns the trends of opt.Period in each
+func GetTrendReport(messages []Message, opt TrendOptions) (report TrendReport) {
+	all := AnalyzeMessages(messages)
+	if opt.Period == "" {
+		opt.Period = latestPeriod(all.MonthlyStats)
+	}
+	report = TrendReport{
+		Period:    opt.Period,
+		Workspace: GetTermTrends(all.MonthlyStats, opt),
+		Channels:  make(map[string]Trends),
+	}
+	channelMessages := make(map[string][]Message)
+	for _, m := range messages {
+		if m.Channel != "" {
+			channelMessages[m.Channel] = append(channelMessages[m.Channel], m)
+		}
+	}
+	for channel, cm := range channelMessages {
+		report.Channels[channel] = GetTermTrends(AnalyzeMessages(cm).MonthlyStats, opt)
+	}
+	return
+}
+
+// GetTermTrends takes in stats keyed by period (e.g. MonthlyStats or
+// DailyStats) and ranks the words of opt.Period by the z-score of their
+// frequency against the opt.Baseline periods before it; the deviation
+// combines the spread across baseline periods with binomial sampling
+// noise so that words new in the period still get a finite score
+func GetTermTrends(periods map[string]*MessageStats, opt TrendOptions) (trends Trends) {
+	trends = Trends{Rising: []Trend{}, Falling: []Trend{}}
+	keys := make([]string, 0, len(periods))
+	for k := range periods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if opt.Period == "" {
+		opt.Period = latestPeriod(periods)
+	}
+	target, ok := periods[opt.Period]
+	if !ok || target.NumWords == 0 {
+		return
+	}
+	idx := sort.SearchStrings(keys, opt.Period)
+	start := idx - opt.Baseline
+	if start < 0 {
+		start = 0
+	}
+	baseline := []*MessageStats{}
+	baselineWords := 0
+	for _, k := range keys[start:idx] {
+		if periods[k].NumWords > 0 {
+			baseline = append(baseline, periods[k])
+			baselineWords += periods[k].NumWords
+		}
+	}
+	if len(baseline) == 0 {
+		return
+	}
+
+	counts := lowerWordCounts(target.WordCountMap)
+	baselineCounts := make(map[string]int)
+	periodCounts := make([]map[string]int, len(baseline))
+	for i, ms := range baseline {
+		periodCounts[i] = lowerWordCounts(ms.WordCountMap)
+		for w, c := range periodCounts[i] {
+			baselineCounts[w] += c
+		}
+	}
+	seen := make(map[string]bool)
+	for w := range counts {
+		seen[w] = true
+	}
+	for w := range baselineCounts {
+		seen[w] = true
+	}
+	n := float64(target.NumWords)
+	for w := range seen {
+		if !opt.IncludeCommon && isTrendStopword(w, target, baseline) {
+			continue
+		}
+		c := counts[w]
+		avgBaselineCount := float64(baselineCounts[w]) / float64(len(baseline))
+		if c < opt.MinCount && avgBaselineCount < float64(opt.MinCount) {
+			continue
+		}
+		freqs := make([]float64, len(baseline))
+		mean := 0.0
+		for i, ms := range baseline {
+			freqs[i] = float64(periodCounts[i][w]) / float64(ms.NumWords)
+			mean += freqs[i]
+		}
+		mean /= float64(len(baseline))
+		variance := 0.0
+		for _, f := range freqs {
+			variance += (f - mean) * (f - mean)
+		}
+		variance /= float64(len(baseline))
+		p := math.Max(mean, 1/float64(baselineWords))
+		variance += p * (1 - p) / n
+		freq := float64(c) / n
+		t := Trend{
+			Word:              w,
+			Count:             c,
+			Frequency:         freq,
+			BaselineFrequency: mean,
+			ZScore:            (freq - mean) / math.Sqrt(variance),
+		}
+		if mean > 0 {
+			t.Change = freq / mean
+		}
+		switch {
+		case baselineCounts[w] == 0:
+			t.Direction = "new"
+		case t.ZScore >= 0:
+			t.Direction = "rising"
+		default:
+			t.Direction = "falling"
+		}
+		if t.ZScore >= 0 && c >= opt.MinCount {
+			trends.Rising = append(trends.Rising, t)
+		} else if t.ZScore < 0 && avgBaselineCount >= float64(opt.MinCount) {
+			trends.Falling = append(trends.Falling, t)
+		}
+	}
+	sort.SliceStable(trends.Rising, func(i, j int) bool {
+		if trends.Rising[i].ZScore == trends.Rising[j].ZScore {
+			return trends.Rising[i].Word < trends.Rising[j].Word
+		}
+		return trends.Rising[i].ZScore > trends.Rising[j].ZScore
+	})
+	sort.SliceStable(trends.Falling, func(i, j int) bool {
+		if trends.Falling[i].ZScore == trends.Falling[j].ZScore {
+			return trends.Falling[i].Word < trends.Falling[j].Word
+		}
+		return trends.Falling[i].ZScore < trends.Falling[j].ZScore
+	})
+	if len(trends.Rising) > opt.Amount {
+		trends.Rising = trends.Rising[:opt.Amount]
+	}
+	if len(trends.Falling) > opt.Amount {
+		trends.Falling = trends.Falling[:opt.Amount]
+	}
+	return
+}
+
+// isTrendStopword determines whether a word is used as a stopword
+// in the period or in any of the baseline periods
+func isTrendStopword(w string, target *MessageStats, baseline []*MessageStats) bool {
+	if target.isStopword(w) {
+		return true
+	}
+	for _, ms := range baseline {
+		if ms.isStopword(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestPeriod returns the last key of
+// period stats in lexicographic order
+func latestPeriod(periods map[string]*MessageStats) (latest string) {
+	for k := range periods {
+		if k > latest {
+			latest = k
+		}
+	}
+	return
+}
+
+// lowerWordCounts merges the counts of
+// words that only differ in case
+func lowerWordCounts(wordCountMap map[string]int) (counts map[string]int) {
+	counts = make(map[string]int)
+	for w, c := range wordCountMap {
+		counts[strings.ToLower(w)] += c
+	}
+	return
+}
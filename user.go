@@ -70,3 +70,15 @@ func GetUsers(dataPath string) (users []*User, err error) {
 	err = json.Unmarshal(usersBytes, &users)
 	return
 }
+
+// GetUserName returns the display name of a user,
+// falling back to their real name and then their handle
+func GetUserName(u *User) string {
+	if u.Profile.DisplayName != "" {
+		return u.Profile.DisplayName
+	}
+	if u.Profile.RealName != "" {
+		return u.Profile.RealName
+	}
+	return u.Name
+}